@@ -26,7 +26,9 @@ func initCLI() *CLI {
 	instance.Version = Version
 	instance.Action = getDefaultAction(app.config)
 	instance.Commands = []cli.Command{
+		getConfigCommand(app.config, app.rawLogger),
 		getInitCommand(app.config),
+		getLogsCommand(app.config, os.Stdout),
 		getTestCommand(app.config),
 		getVersionCommand(app.config, app.rawLogger),
 		getViewCommand(app.config, app.rawLogger),
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/urfave/cli"
+)
+
+func getConfigCommand(config *Config, logger *Logger) cli.Command {
+	return cli.Command{
+		Aliases:     []string{"c"},
+		Description: "print or validate the configuration godev resolves from flags, environment variables and the config file",
+		Name:        "config",
+		Usage:       "print or validate the effective configuration",
+		Subcommands: []cli.Command{
+			{
+				Action:      getConfigPrintAction(config, logger),
+				Description: "print the fully resolved configuration and where each value came from",
+				Flags:       append(getConfigFlags(), getFlagFormat()),
+				Name:        "print",
+				Usage:       "print the effective configuration",
+			},
+			{
+				Action:      getConfigValidateAction(config, logger),
+				Description: "check that the execution groups can be parsed and run, and that the directories exist",
+				Flags:       getConfigFlags(),
+				Name:        "validate",
+				Usage:       "validate the effective configuration",
+			},
+		},
+	}
+}
+
+func getConfigFlags() []cli.Flag {
+	return append(getDefaultFlags(), getFlagTestMode())
+}
+
+func getConfigPrintAction(config *Config, logger *Logger) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := resolveConfigForConfigCommand(c, config); err != nil {
+			return err
+		}
+		output, err := config.GetReport().Marshal(c.String("format"))
+		if err != nil {
+			return err
+		}
+		logger.Info(string(output))
+		return nil
+	}
+}
+
+func getConfigValidateAction(config *Config, logger *Logger) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := resolveConfigForConfigCommand(c, config); err != nil {
+			return err
+		}
+		if err := config.Validate(); err != nil {
+			return err
+		}
+		logger.Info("configuration is valid")
+		return nil
+	}
+}
+
+// resolveConfigForConfigCommand resolves the configuration the same
+// way `godev` (or `godev test` if --test is set) would
+func resolveConfigForConfigCommand(c *cli.Context, config *Config) error {
+	config.RunConfig = true
+	config.RunTest = c.Bool("test")
+	if err := loadConfigLayers(c, config); err != nil {
+		return err
+	}
+	config.assignDefaults()
+	config.interpretLogLevel()
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/urfave/cli"
+)
+
+type CLIConfigHandlerTestSuite struct {
+	suite.Suite
+	logs   bytes.Buffer
+	logger *Logger
+}
+
+func TestCLIConfigHandler(t *testing.T) {
+	suite.Run(t, new(CLIConfigHandlerTestSuite))
+}
+
+func (s *CLIConfigHandlerTestSuite) SetupTest() {
+	s.logs.Reset()
+	s.logger = InitLogger(&LoggerConfig{Name: "CLIConfigHandlerTestSuite", Format: "raw", Level: "trace"})
+	s.logger.SetOutput(&s.logs)
+}
+
+func (s *CLIConfigHandlerTestSuite) Test_getConfigCommand() {
+	command := getConfigCommand(&Config{}, s.logger)
+	assert.Equal(s.T(), "config", command.Name)
+	assert.Len(s.T(), command.Subcommands, 2)
+	assert.Equal(s.T(), "print", command.Subcommands[0].Name)
+	assert.Equal(s.T(), "validate", command.Subcommands[1].Name)
+}
+
+func (s *CLIConfigHandlerTestSuite) Test_getConfigFlags() {
+	ensureCLIFlags(s.T(), []string{"exts", "profile", "test"}, getConfigFlags())
+}
+
+func (s *CLIConfigHandlerTestSuite) Test_getConfigPrintAction() {
+	t := s.T()
+	config := Config{}
+	mockApp := cli.NewApp()
+	mockApp.Flags = append(getConfigFlags(), getFlagFormat())
+	mockApp.Action = getConfigPrintAction(&config, s.logger)
+	err := mockApp.Run([]string{"test-config-print", "--format", "json", "--args", "--port 8080", "--test"})
+	assert.Nil(t, err)
+	assert.True(t, config.RunConfig)
+	var report ConfigReport
+	assert.Nil(t, json.Unmarshal(s.logs.Bytes(), &report))
+	assert.Equal(t, "test", report.Mode)
+	assert.Equal(t, ConfigSourceDefault, report.Values["exec"].Source)
+	assert.Equal(t, ConfigSourceDefault, report.Values["args"].Source)
+	pathToBinary := path.Join(getCurrentWorkingDirectory(), "/bin/app")
+	assert.Equal(t, [][]string{
+		{"go mod vendor"},
+		{"go build -o " + pathToBinary},
+		{"go test ./... -coverprofile c.out"},
+	}, report.Pipeline)
+}
+
+func (s *CLIConfigHandlerTestSuite) Test_getConfigValidateAction() {
+	t := s.T()
+	config := Config{}
+	mockApp := cli.NewApp()
+	mockApp.Flags = getConfigFlags()
+	mockApp.Action = getConfigValidateAction(&config, s.logger)
+	err := mockApp.Run([]string{"test-config-validate", "--exec", "go version", "--exec", "this-does-not-exist 'a"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "execution group 2, command 1")
+	assert.NotContains(t, s.logs.String(), "configuration is valid")
+}
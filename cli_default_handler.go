@@ -1,9 +1,8 @@
 package main
 
 import (
-	"strings"
+	"os"
 
-	shellquote "github.com/kballard/go-shellquote"
 	"github.com/urfave/cli"
 )
 
@@ -12,13 +11,29 @@ func getDefaultFlags() []cli.Flag {
 		getFlagBuildOutput(),
 		getFlagCommandArguments(),
 		getFlagCommandsDelimiter(),
+		getFlagCrashLoopExits(),
+		getFlagCrashLoopWindow(),
+		getFlagEnvFiles(),
+		getFlagEnvInherit(),
 		getFlagEnvVars(),
 		getFlagExecGroups(),
 		getFlagFileExtensions(),
 		getFlagIgnoredNames(),
+		getFlagLogsKeepRuns(),
+		getFlagLogsMaxSize(),
+		getFlagMaxParallel(),
+		getFlagOutputMode(),
+		getFlagProfile(),
+		getFlagPTY(),
 		getFlagRate(),
+		getFlagRestartAlways(),
+		getFlagRestartPolicy(),
+		getFlagShell(),
 		getFlagSilent(),
+		getFlagStdin(),
+		getFlagStopSignals(),
 		getFlagSuperVerboseLogs(),
+		getFlagSwap(),
 		getFlagVerboseLogs(),
 		getFlagWatchDirectory(),
 		getFlagWorkDirectory(),
@@ -27,20 +42,10 @@ func getDefaultFlags() []cli.Flag {
 
 func getDefaultAction(config *Config) cli.ActionFunc {
 	return func(c *cli.Context) error {
-		var err error
-		config.RunDefault = true
-		config.BuildOutput = c.String("output")
-		if config.CommandArguments, err = shellquote.Split(c.String("args")); err != nil {
-			panic(err)
+		if err := loadConfigLayers(c, config); err != nil {
+			return err
 		}
-		config.CommandsDelimiter = c.String("exec-delim")
-		config.EnvVars = c.StringSlice("env")
-		config.ExecGroups = c.StringSlice("exec")
-		config.FileExtensions = strings.Split(c.String("exts"), ",")
-		config.IgnoredNames = strings.Split(c.String("ignore"), ",")
-		config.Rate = c.Duration("rate")
-		config.WatchDirectory = c.String("watch")
-		config.WorkDirectory = c.String("dir")
+		config.RunDefault = true
 		config.assignDefaults()
 		config.LogSilent = c.Bool("silent")
 		config.LogVerbose = c.Bool("verbose")
@@ -49,3 +54,23 @@ func getDefaultAction(config *Config) cli.ActionFunc {
 		return nil
 	}
 }
+
+// loadConfigLayers applies the config file, the selected profile, GODEV_*
+// environment variables and the flags set in :c onto :config
+func loadConfigLayers(c *cli.Context, config *Config) error {
+	if profile, ok := os.LookupEnv(ConfigEnvironmentPrefix + "PROFILE"); ok {
+		config.Profile = profile
+	}
+	if isFlagSet(c, "profile", "p") {
+		config.Profile = c.String("profile")
+	}
+	flags, err := getConfigLayerFromFlags(c)
+	if err != nil {
+		return err
+	}
+	environment, err := getConfigLayerFromEnvironment(os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	return config.applyLayers(flags, environment)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	"path"
 	"testing"
 	"time"
@@ -30,15 +31,31 @@ func (s *CLIDefaultHandlerTestSuite) Test_getDefaultFlags() {
 	ensureCLIFlags(s.T(),
 		[]string{
 			"args",
+			"crash-loop-exits",
+			"crash-loop-window",
 			"dir",
 			"env",
+			"env-file",
+			"env-inherit",
 			"exec-delim",
 			"exec",
 			"exts",
 			"ignore",
+			"logs-keep-runs",
+			"logs-max-size",
+			"max-parallel",
 			"output",
+			"output-mode",
+			"profile",
+			"pty",
 			"rate",
+			"restart-always",
+			"restart-policy",
+			"shell",
 			"silent",
+			"stdin",
+			"stop-signals",
+			"swap",
 			"verbose",
 			"vverbose",
 			"watch",
@@ -68,3 +85,17 @@ func (s *CLIDefaultHandlerTestSuite) Test_getDefaultAction() {
 		panic(err)
 	}
 }
+
+func (s *CLIDefaultHandlerTestSuite) Test_getDefaultAction_ignoresUnprefixedEnvironment() {
+	t := s.T()
+	os.Setenv("DIR", "/not/the/work/directory")
+	os.Setenv("watch", "/not/the/watch/directory")
+	defer os.Unsetenv("DIR")
+	defer os.Unsetenv("watch")
+	config := Config{}
+	s.mockApp.Action = getDefaultAction(&config)
+	assert.Nil(t, s.mockApp.Run([]string{"test-run"}))
+	assert.Equal(t, getCurrentWorkingDirectory(), config.WorkDirectory)
+	assert.Equal(t, getCurrentWorkingDirectory(), config.WatchDirectory)
+	assert.Equal(t, ConfigSourceDefault, config.Sources["dir"])
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// CLILogsFollowInterval is how often the log files are checked for new
+// output when following them
+const CLILogsFollowInterval = 250 * time.Millisecond
+
+func getLogsCommand(config *Config, output io.Writer) cli.Command {
+	return cli.Command{
+		Action:      getLogsAction(config, output, nil),
+		Aliases:     []string{"l"},
+		ArgsUsage:   "[run-id] [step]",
+		Description: "print the output of the commands in a pipeline run from the log files in " + DefaultLogsDirectory + "/<session>-<run-id>/<execution group>/<command>.log where [run-id] defaults to the latest run, a run id without its session (eg. 2 instead of 20261018T110300-2) refers to the run in the latest session, and [step] is the name of an execution group, optionally followed by a slash and the name of a command in it (eg. 2/a1b2c3)",
+		Flags:       []cli.Flag{getFlagFollow(), getFlagWorkDirectory()},
+		Name:        "logs",
+		Usage:       "print or follow the output of commands in pipeline runs",
+	}
+}
+
+// getLogsAction returns the action of the logs sub-command, which
+// follows the log files until :stop is closed when --follow is set
+func getLogsAction(config *Config, output io.Writer, stop <-chan bool) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		config.RunLogs = true
+		config.WorkDirectory = c.String("dir")
+		config.interpretLogLevel()
+		reader := &RunLogsReader{
+			logs:    &RunLogs{Directory: path.Join(config.WorkDirectory, DefaultLogsDirectory)},
+			output:  output,
+			step:    strings.Trim(c.Args().Get(1), "/"),
+			offsets: map[string]int64{},
+		}
+		reader.run = c.Args().First()
+		latest := len(reader.run) == 0
+		if _, err := strconv.Atoi(reader.run); err != nil && !latest {
+			if _, _, ok := parseRunName(reader.run); !ok {
+				return fmt.Errorf("invalid run id '%s': should be a number optionally prefixed by its session (eg. 20261018T110300-2)", reader.run)
+			}
+		}
+		if err := reader.find(latest); err != nil {
+			return err
+		}
+		if !c.Bool("follow") {
+			return reader.print(true)
+		}
+		for {
+			if err := reader.print(false); err != nil {
+				return err
+			}
+			select {
+			case <-stop:
+				return nil
+			case <-time.After(CLILogsFollowInterval):
+			}
+			if latest {
+				if err := reader.find(latest); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// RunLogsReader prints the log files of the steps in a run which match
+// its step, only printing what was written since the last print
+type RunLogsReader struct {
+	logs     *RunLogs
+	output   io.Writer
+	run      string
+	step     string
+	offsets  map[string]int64
+	lastPath string
+}
+
+// find makes sure the run exists, switching to the run which started
+// last when :latest is set. A run id without its session refers to the
+// run in the session which started last
+func (reader *RunLogsReader) find(latest bool) error {
+	runs, err := reader.logs.GetRuns()
+	if err != nil {
+		return err
+	} else if len(runs) == 0 {
+		return fmt.Errorf("no pipeline runs have been logged to '%s'", reader.logs.Directory)
+	}
+	if latest {
+		reader.run = runs[len(runs)-1]
+		return nil
+	}
+	if runID, err := strconv.Atoi(reader.run); err == nil {
+		reader.logs.Session, _, _ = parseRunName(runs[len(runs)-1])
+		reader.run = reader.logs.GetRunName(runID)
+	}
+	for _, run := range runs {
+		if run == reader.run {
+			return nil
+		}
+	}
+	return fmt.Errorf("no logs for pipeline run %s, the runs with logs are: %s", reader.run, strings.Join(runs, ", "))
+}
+
+// print writes what was added to the log files of the matching steps
+// since they were last printed, each file is introduced by a header
+// whenever the output switches to it. When :mustMatch is set an error
+// is returned if no step matches, steps may not have started yet when
+// following
+func (reader *RunLogsReader) print(mustMatch bool) error {
+	steps, err := reader.logs.GetSteps(reader.run)
+	if err != nil {
+		return err
+	}
+	var matched []string
+	for _, step := range steps {
+		if len(reader.step) == 0 || step == reader.step || strings.HasPrefix(step, reader.step+"/") {
+			matched = append(matched, step)
+		}
+	}
+	if len(matched) == 0 && len(reader.step) > 0 && mustMatch {
+		return fmt.Errorf("no step '%s' in pipeline run %s, its steps are: %s", reader.step, reader.run, strings.Join(steps, ", "))
+	}
+	for _, step := range matched {
+		if err := reader.printStep(step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printStep writes what was added to the log file of :step since it
+// was last printed, from the start if the file was truncated in the
+// meantime
+func (reader *RunLogsReader) printStep(step string) error {
+	filePath := path.Join(reader.logs.Directory, reader.run, step+RunLogsExtension)
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		// the run was pruned while it was being followed
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.Size() < reader.offsets[filePath] {
+		reader.offsets[filePath] = 0
+	}
+	if _, err := file.Seek(reader.offsets[filePath], io.SeekStart); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	if reader.lastPath != filePath {
+		if len(reader.lastPath) > 0 {
+			fmt.Fprintln(reader.output)
+		}
+		fmt.Fprintf(reader.output, "==> %s/%s%s <==\n", reader.run, step, RunLogsExtension)
+		reader.lastPath = filePath
+	}
+	reader.offsets[filePath] += int64(len(data))
+	_, err = reader.output.Write(data)
+	return err
+}
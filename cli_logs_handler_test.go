@@ -0,0 +1,158 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/urfave/cli"
+)
+
+type CLILogsHandlerTestSuite struct {
+	suite.Suite
+	workDirectory string
+	logs          *RunLogs
+}
+
+func TestCLILogsHandler(t *testing.T) {
+	suite.Run(t, new(CLILogsHandlerTestSuite))
+}
+
+func (s *CLILogsHandlerTestSuite) SetupTest() {
+	directory, err := ioutil.TempDir("", "godev-cli-logs")
+	if err != nil {
+		panic(err)
+	}
+	s.workDirectory = directory
+	s.logs = &RunLogs{Directory: path.Join(directory, DefaultLogsDirectory), Session: "20261018T090000"}
+	s.writeLog(2, "build", "a1b2c3", "built in an earlier session\n")
+	s.logs.Session = "20261018T100000"
+	s.writeLog(1, "build", "a1b2c3", "built\n")
+	s.writeLog(1, "test", "d4e5f6", "tested\n")
+	s.writeLog(2, "build", "a1b2c3", "built again\n")
+}
+
+func (s *CLILogsHandlerTestSuite) TearDownTest() {
+	os.RemoveAll(s.workDirectory)
+}
+
+func (s *CLILogsHandlerTestSuite) writeLog(runID int, group string, command string, data string) {
+	file, err := openLogFile(s.logs.GetPath(runID, group, command))
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	file.Write([]byte(data))
+}
+
+// runLogs runs the logs sub-command with :args and returns its output
+func (s *CLILogsHandlerTestSuite) runLogs(args ...string) (*Config, string, error) {
+	config := Config{}
+	var output lockedBuffer
+	mockApp := cli.NewApp()
+	mockApp.Flags = []cli.Flag{getFlagFollow(), getFlagWorkDirectory()}
+	mockApp.Action = getLogsAction(&config, &output, nil)
+	err := mockApp.Run(append([]string{"test-logs", "--dir", s.workDirectory}, args...))
+	return &config, output.String(), err
+}
+
+func (s *CLILogsHandlerTestSuite) Test_getLogsCommand() {
+	command := getLogsCommand(&Config{}, ioutil.Discard)
+	ensureCLICommand(s.T(), command, []string{"logs", "l"}, []cli.Flag{getFlagFollow(), getFlagWorkDirectory()})
+}
+
+func (s *CLILogsHandlerTestSuite) Test_getLogsAction_latestRun() {
+	t := s.T()
+	config, output, err := s.runLogs()
+	assert.Nil(t, err)
+	assert.True(t, config.RunLogs)
+	assert.Equal(t, "panic", config.LogLevel.String())
+	assert.Equal(t, "==> 20261018T100000-2/build/a1b2c3.log <==\nbuilt again\n", output)
+}
+
+func (s *CLILogsHandlerTestSuite) Test_getLogsAction_withRunAndStep() {
+	t := s.T()
+	_, output, err := s.runLogs("1")
+	assert.Nil(t, err)
+	assert.Equal(t, "==> 20261018T100000-1/build/a1b2c3.log <==\nbuilt\n\n==> 20261018T100000-1/test/d4e5f6.log <==\ntested\n", output)
+	_, output, err = s.runLogs("1", "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "==> 20261018T100000-1/test/d4e5f6.log <==\ntested\n", output)
+	_, output, err = s.runLogs("20261018T100000-1", "build/a1b2c3")
+	assert.Nil(t, err)
+	assert.Equal(t, "==> 20261018T100000-1/build/a1b2c3.log <==\nbuilt\n", output)
+	_, output, err = s.runLogs("20261018T090000-2")
+	assert.Nil(t, err)
+	assert.Equal(t, "==> 20261018T090000-2/build/a1b2c3.log <==\nbuilt in an earlier session\n", output)
+}
+
+func (s *CLILogsHandlerTestSuite) Test_getLogsAction_withUnknownRunOrStep() {
+	t := s.T()
+	_, _, err := s.runLogs("3")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no logs for pipeline run 20261018T100000-3, the runs with logs are: 20261018T090000-2, 20261018T100000-1, 20261018T100000-2")
+	_, _, err = s.runLogs("1", "deploy")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no step 'deploy' in pipeline run 20261018T100000-1, its steps are: build/a1b2c3, test/d4e5f6")
+	_, _, err = s.runLogs("first")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid run id 'first'")
+	_, _, err = s.runLogs("20261018-1")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid run id '20261018-1'")
+	os.RemoveAll(s.logs.Directory)
+	_, _, err = s.runLogs()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no pipeline runs have been logged")
+}
+
+func (s *CLILogsHandlerTestSuite) Test_getLogsAction_follow() {
+	t := s.T()
+	config := Config{}
+	var output lockedBuffer
+	stop := make(chan bool)
+	mockApp := cli.NewApp()
+	mockApp.Flags = []cli.Flag{getFlagFollow(), getFlagWorkDirectory()}
+	mockApp.Action = getLogsAction(&config, &output, stop)
+	exited := make(chan error, 1)
+	go func() {
+		exited <- mockApp.Run([]string{"test-logs", "--dir", s.workDirectory, "--follow"})
+	}()
+	assert.True(t, waitFor(func() bool { return output.String() == "==> 20261018T100000-2/build/a1b2c3.log <==\nbuilt again\n" }))
+	s.writeLog(2, "build", "a1b2c3", "still building\n")
+	assert.True(t, waitFor(func() bool {
+		return output.String() == "==> 20261018T100000-2/build/a1b2c3.log <==\nbuilt again\nstill building\n"
+	}))
+	s.writeLog(3, "build", "a1b2c3", "new run\n")
+	assert.True(t, waitFor(func() bool {
+		return len(output.String()) > len("==> 20261018T100000-2/build/a1b2c3.log <==\nbuilt again\nstill building\n")
+	}))
+	close(stop)
+	assert.Nil(t, <-exited)
+	assert.Equal(t, "==> 20261018T100000-2/build/a1b2c3.log <==\nbuilt again\nstill building\n\n==> 20261018T100000-3/build/a1b2c3.log <==\nnew run\n", output.String())
+}
+
+func (s *CLILogsHandlerTestSuite) Test_getLogsAction_followStopsWhenLogsAreRemoved() {
+	t := s.T()
+	var output lockedBuffer
+	mockApp := cli.NewApp()
+	mockApp.Flags = []cli.Flag{getFlagFollow(), getFlagWorkDirectory()}
+	mockApp.Action = getLogsAction(&Config{}, &output, make(chan bool))
+	exited := make(chan error, 1)
+	go func() {
+		exited <- mockApp.Run([]string{"test-logs", "--dir", s.workDirectory, "--follow"})
+	}()
+	assert.True(t, waitFor(func() bool { return len(output.String()) > 0 }))
+	os.RemoveAll(s.logs.Directory)
+	select {
+	case err := <-exited:
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "no pipeline runs have been logged")
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "following should stop once there are no logs to follow")
+	}
+}
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"strings"
-
 	"github.com/urfave/cli"
 )
 
@@ -21,11 +19,17 @@ func getTestFlags() []cli.Flag {
 	return []cli.Flag{
 		getFlagBuildOutput(),
 		getFlagCommandsDelimiter(),
+		getFlagEnvFiles(),
+		getFlagEnvInherit(),
 		getFlagEnvVars(),
 		getFlagFileExtensions(),
 		getFlagIgnoredNames(),
+		getFlagProfile(),
+		getFlagPTY(),
 		getFlagRate(),
+		getFlagShell(),
 		getFlagSilent(),
+		getFlagStopSignals(),
 		getFlagSuperVerboseLogs(),
 		getFlagVerboseLogs(),
 		getFlagWatchDirectory(),
@@ -36,14 +40,9 @@ func getTestFlags() []cli.Flag {
 func getTestAction(config *Config) cli.ActionFunc {
 	return func(c *cli.Context) error {
 		config.RunTest = true
-		config.BuildOutput = c.String("output")
-		config.CommandsDelimiter = c.String("exec-delim")
-		config.EnvVars = c.StringSlice("env")
-		config.FileExtensions = strings.Split(c.String("exts"), ",")
-		config.IgnoredNames = strings.Split(c.String("ignore"), ",")
-		config.Rate = c.Duration("rate")
-		config.WatchDirectory = c.String("watch")
-		config.WorkDirectory = c.String("dir")
+		if err := loadConfigLayers(c, config); err != nil {
+			return err
+		}
 		config.assignDefaults()
 		config.LogSilent = c.Bool("silent")
 		config.LogVerbose = c.Bool("verbose")
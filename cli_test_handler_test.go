@@ -37,12 +37,18 @@ func (s *CLITestHandlerTestSuite) Test_getTestFlags() {
 		[]string{
 			"dir",
 			"env",
+			"env-file",
+			"env-inherit",
 			"exec-delim",
 			"exts",
 			"ignore",
 			"output",
+			"profile",
+			"pty",
 			"rate",
+			"shell",
 			"silent",
+			"stop-signals",
 			"verbose",
 			"vverbose",
 			"watch",
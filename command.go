@@ -4,11 +4,14 @@ import (
 	"crypto/md5"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 // CommandDelimiter is used when demarcating boundaries between
@@ -59,26 +62,76 @@ func InitCommand(config *CommandConfig) *Command {
 
 // CommandConfig configures Command
 type CommandConfig struct {
-	Application string
-	Arguments   []string
-	Directory   string
-	Environment []string
-	LogLevel    LogLevel
+	Application        string
+	Arguments          []string
+	ContinueOnError    bool
+	Directory          string
+	Environment        []string
+	EnvironmentInherit string
+	GodevEnvironment   []string
+	LogLevel           LogLevel
+	Name               string
+	PTY                bool
+	Retries            int
+	StopSignals        StopSignals
+	Timeout            time.Duration
 }
 
-// Command is the atomic command to run
+// Command is the atomic command to run. Its output is written through
+// the multiplexer in the outputMode, which defaults to the mode of the
+// multiplexer, to the file at logPath within the limits of logs and to
+// output when they're set. The input of stdin is forwarded to it when
+// it's set. A command with a timeout is stopped once its deadline
+// passes. A failing command is run again until it has been retried
+// config.Retries times
 type Command struct {
-	id         string
-	signal     chan os.Signal
-	status     chan error
-	run        chan error
-	terminated chan error
-	config     *CommandConfig
-	cmd        *exec.Cmd
-	logger     *Logger
-	started    bool
-	reported   bool
-	stopped    bool
+	id          string
+	signal      chan os.Signal
+	status      chan error
+	run         chan error
+	terminated  chan error
+	deadline    <-chan time.Time
+	config      *CommandConfig
+	pipelineRun *PipelineRun
+	cmd         *exec.Cmd
+	logger      *Logger
+	multiplexer *OutputMultiplexer
+	outputMode  string
+	streams     *CommandOutput
+	stdin       *StdinForwarder
+	output      io.Writer
+	logs        *RunLogs
+	logPath     string
+	logFile     io.WriteCloser
+	attempts    int
+	signalled   bool
+	started     bool
+	reported    bool
+	stopped     bool
+	interrupted bool
+	spawned     chan bool
+	exited      chan bool
+	stateMutex  sync.Mutex
+}
+
+// CommandRetryBackoffInitial is the delay before a failed command is
+// retried for the first time, it doubles with every retry up to
+// CommandRetryBackoffMax
+const CommandRetryBackoffInitial = time.Second
+
+// CommandRetryBackoffMax is the longest delay before a failed command
+// is retried
+const CommandRetryBackoffMax = 30 * time.Second
+
+// CommandTimeoutError is the error of a command which was stopped
+// because it ran for longer than its timeout
+type CommandTimeoutError struct {
+	Timeout time.Duration
+}
+
+// Error describes the timeout
+func (err *CommandTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", err.Timeout)
 }
 
 // GetID returns the command's ID, used for the execution group
@@ -87,6 +140,15 @@ func (command *Command) GetID() string {
 	return command.id
 }
 
+// GetName returns the name of the command for prefixing its output,
+// which defaults to its ID
+func (command *Command) GetName() string {
+	if len(command.config.Name) > 0 {
+		return command.config.Name
+	}
+	return command.id
+}
+
 // GetStatus returns the command's status channel for the execution
 // group to know when the command has terminated
 func (command *Command) GetStatus() *chan error {
@@ -96,6 +158,8 @@ func (command *Command) GetStatus() *chan error {
 // IsRunning allows callers to check if the command is running,
 // the logic is tied into the Run()
 func (command *Command) IsRunning() bool {
+	command.stateMutex.Lock()
+	defer command.stateMutex.Unlock()
 	return command.started && !command.stopped
 }
 
@@ -120,48 +184,145 @@ func (command *Command) IsValid() error {
 	return nil
 }
 
-// Run executes the command
+// Run executes the command, retrying it if it fails and has retries
+// left unless it was signalled to stop
 func (command *Command) Run() {
 	command.logger.Tracef("command[%s] is starting", command.id)
-	command.handleInitialisation()
-	go command.handleStart()
-	go command.handleProcessLifecycle()
+	command.stateMutex.Lock()
+	command.interrupted = false
+	command.exited = make(chan bool)
+	command.stateMutex.Unlock()
+	command.attempts = 0
+	command.signalled = false
+	for {
+		command.attempts++
+		command.handleInitialisation()
+		go command.handleStart()
+		go command.handleProcessLifecycle()
+		terminateCommand := <-command.terminated
+		if terminateCommand == nil || command.signalled || command.attempts > command.config.Retries {
+			command.handleStopped(terminateCommand)
+			return
+		}
+		if err := command.waitToRetry(terminateCommand); err != nil {
+			command.handleStopped(err)
+			return
+		}
+	}
+}
+
+// waitToRetry waits for the backoff before the command is run again
+// after failing with :err, the error to stop with is returned if the
+// command is signalled to stop in the meantime
+func (command *Command) waitToRetry(err error) error {
+	backoff := CommandRetryBackoffInitial
+	for retry := 1; retry < command.attempts && backoff < CommandRetryBackoffMax; retry++ {
+		backoff *= 2
+	}
+	if backoff > CommandRetryBackoffMax {
+		backoff = CommandRetryBackoffMax
+	}
+	command.logger.Warnf(
+		"command[%s] failed (%s), retrying in %s (retry %v of %v)",
+		command.id, err, backoff, command.attempts, command.config.Retries,
+	)
 	select {
-	case terminateCommand := <-command.terminated:
-		command.handleStopped(terminateCommand)
+	case <-time.After(backoff):
+		return nil
+	case signal := <-command.signal:
+		command.signalled = true
+		return errors.New(signal.String())
 	}
 }
 
-// SendInterrupt sends SIGINT to the command
+// SendInterrupt sends SIGINT to the command, only the first interrupt
+// of a run is sent and it's dropped if the command exits before it's
+// received so that callers never block on a command which has exited
 func (command *Command) SendInterrupt() {
+	command.stateMutex.Lock()
+	signal, exited, interrupted := command.signal, command.exited, command.interrupted
+	command.interrupted = true
+	command.stateMutex.Unlock()
+	if interrupted {
+		return
+	}
 	command.logger.Tracef("SIGINT received by command %s", command.id)
-	command.logger.Tracef("command[%v] status: %v/%v, msg: SIGINT >>> %v", command.id, command.started, command.terminated, &command.signal)
-	command.signal <- syscall.SIGINT
+	command.logger.Tracef("command[%v] status: %v/%v, msg: SIGINT >>> %v", command.id, command.IsRunning(), command.terminated, &signal)
+	select {
+	case signal <- syscall.SIGINT:
+	case <-exited:
+	}
+}
+
+// isInterrupted checks whether an interrupt was sent during the
+// command's current run
+func (command *Command) isInterrupted() bool {
+	command.stateMutex.Lock()
+	defer command.stateMutex.Unlock()
+	return command.interrupted
 }
 
 func (command *Command) handleInitialisation() {
 	if command.config == nil {
 		panic("command.config needs to be defined before initialisation can be done")
 	}
-	command.signal = make(chan os.Signal, 0)
+	if command.attempts <= 1 {
+		// retries keep the channel that signals may already be sent on
+		command.stateMutex.Lock()
+		command.signal = make(chan os.Signal, 0)
+		command.stateMutex.Unlock()
+	}
 	command.status = make(chan error, 0)
-	command.run = make(chan error, 0)
+	command.run = make(chan error, 1)
 	command.terminated = make(chan error, 0)
+	command.spawned = make(chan bool)
+	command.deadline = nil
+	if command.config.Timeout > 0 {
+		command.deadline = time.After(command.config.Timeout)
+	}
+	command.stateMutex.Lock()
 	command.started = false
-	command.reported = false
 	command.stopped = false
+	command.stateMutex.Unlock()
+	command.reported = false
 	command.cmd = exec.Command(
-		command.config.Application,
-		command.config.Arguments...,
+		command.pipelineRun.Interpolate(command.config.Application),
+		command.pipelineRun.InterpolateArguments(command.config.Arguments)...,
 	)
 	command.cmd.Dir = command.config.Directory
-	command.cmd.Env = command.config.Environment
-	for _, envvar := range os.Environ() {
-		command.cmd.Env = append(command.cmd.Env, envvar)
+	setProcessGroup(command.cmd)
+	providedEnvironment := append([]string{}, command.config.GodevEnvironment...)
+	providedEnvironment = append(providedEnvironment, command.pipelineRun.Environment()...)
+	providedEnvironment = append(providedEnvironment, command.config.Environment...)
+	environment, err := composeEnvironment(os.Environ(), command.config.EnvironmentInherit, providedEnvironment)
+	if err != nil {
+		environment, _ = composeEnvironment(nil, EnvironmentInheritNone, providedEnvironment)
+	}
+	command.cmd.Env = environment
+	if command.logger != nil {
+		if err != nil {
+			command.logger.Errorf("command[%s] is not inheriting any environment: %s", command.id, err)
+		}
+		command.logger.Debugf("command[%s] environment:\n  %s", command.id, strings.Join(environment, "\n  "))
+	}
+	command.streams = command.multiplexer.Open(command.GetName(), command.outputMode)
+	stderr := []io.Writer{command.streams.Stderr}
+	stdout := []io.Writer{command.streams.Stdout}
+	command.logFile = nil
+	if len(command.logPath) > 0 {
+		if command.logFile, err = command.logs.OpenFile(command.logPath); err != nil {
+			command.logger.Warnf("command[%s] output is not being logged to a file: %s", command.id, err)
+		} else {
+			stderr = append(stderr, command.logFile)
+			stdout = append(stdout, command.logFile)
+		}
 	}
-	// command.cmd.Env = append(command.config.Environment, "GOCACHE=on")
-	command.cmd.Stderr = os.Stderr
-	command.cmd.Stdout = os.Stdout
+	if command.output != nil {
+		stderr = append(stderr, command.output)
+		stdout = append(stdout, command.output)
+	}
+	command.cmd.Stderr = io.MultiWriter(stderr...)
+	command.cmd.Stdout = io.MultiWriter(stdout...)
 }
 
 // handleProcessExited handles the exit status being sent by the process
@@ -178,6 +339,8 @@ func (command *Command) handleProcessLifecycle() error {
 			return command.handleSignalReceived(signal)
 		case cmdRunStatus := <-command.run: // process -> Command: i'm done here
 			return command.handleProcessExited(cmdRunStatus)
+		case <-command.deadline: // Command -> process: you're taking too long
+			return command.handleTimeout()
 		default: // just run
 			command.handleProcessReporting()
 		}
@@ -203,21 +366,55 @@ func (command *Command) handleProcessReporting() {
 	}
 }
 
-// handleSignalReceived handles the signal received by the caller
+// handleSignalReceived handles the signal received by the caller by
+// stopping the process group, the command is only reported as
+// terminated once the processes in it are gone
 func (command *Command) handleSignalReceived(signal os.Signal) error {
 	command.logger.Tracef("caller sent signal %v", signal)
+	command.signalled = true
+	err := command.stopProcess(signal)
+	if err != nil {
+		command.logger.Warn(err)
+	}
 	command.terminated <- errors.New(signal.String())
-	if err := command.cmd.Process.Signal(signal); err != nil {
+	return err
+}
+
+// handleTimeout stops the process group of a command which ran for
+// longer than its timeout, the command is reported as having timed out
+// once the processes in it are gone
+func (command *Command) handleTimeout() error {
+	command.logger.Warnf("command[%s] timed out after %s, stopping it", command.id, command.config.Timeout)
+	err := command.stopProcess(syscall.SIGINT)
+	if err != nil {
 		command.logger.Warn(err)
-		return err
 	}
-	return nil
+	command.terminated <- &CommandTimeoutError{Timeout: command.config.Timeout}
+	return err
 }
 
-// handleStart starts the process
+// handleStart starts the process, the process, channel and output are
+// held on to so that a process which exits after the command was
+// restarted doesn't report to the new one. The spawned channel is closed
+// once the process has started. The output and log file are closed once
+// the process has exited so that grouped output is written
 func (command *Command) handleStart() {
+	cmd, run, spawned, streams, logFile := command.cmd, command.run, command.spawned, command.streams, command.logFile
+	command.stateMutex.Lock()
 	command.started = true
-	command.run <- command.cmd.Run()
+	command.stateMutex.Unlock()
+	started := func() { close(spawned) }
+	var err error
+	if command.config.PTY {
+		err = runWithPTY(cmd, command.stdin, command.logger, started)
+	} else {
+		err = runWithStdin(cmd, command.stdin, started)
+	}
+	streams.Close()
+	if logFile != nil {
+		logFile.Close()
+	}
+	run <- err
 }
 
 // handleStopped processes the end of a command as reported
@@ -236,6 +433,11 @@ func (command *Command) handleStopped(terminateCommand error) {
 		command.id,
 		CommandProcessStopSymbol,
 	)
+	command.stateMutex.Lock()
 	command.stopped = true
+	if command.exited != nil {
+		close(command.exited)
+	}
+	command.stateMutex.Unlock()
 	command.status <- terminateCommand
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// OutputModeRaw writes the output of commands as-is, which is
+	// what commands running on their own would do
+	OutputModeRaw = "raw"
+	// OutputModePrefixed writes every line of the output of commands
+	// prefixed with the colored name of the command it came from
+	OutputModePrefixed = "prefixed"
+	// OutputModeGrouped buffers the prefixed lines of the output of
+	// each command and writes them as one block once it exits
+	OutputModeGrouped = "grouped"
+)
+
+// OutputPrefixColors are the colors cycled through for the prefixes
+// of commands, in the order the commands first write output
+var OutputPrefixColors = []string{"cyan", "yellow", "green", "violet", "blue", "lcyan", "lyellow", "lgreen", "lviolet", "lblue"}
+
+// OutputStdoutMarker separates the prefix from lines written to stdout
+const OutputStdoutMarker = "|"
+
+// OutputStderrMarker separates the prefix from lines written to
+// stderr, it is colored red to tell them apart from stdout
+const OutputStderrMarker = "!"
+
+// validateOutputMode checks that :mode is a known output mode
+func validateOutputMode(mode string) error {
+	switch mode {
+	case OutputModeRaw, OutputModePrefixed, OutputModeGrouped:
+		return nil
+	}
+	return fmt.Errorf("should be one of '%s', '%s' or '%s'", OutputModeRaw, OutputModePrefixed, OutputModeGrouped)
+}
+
+// OutputMultiplexer writes the output of commands to Stdout and Stderr
+// according to its Mode so that the output of commands running in
+// parallel doesn't interleave mid-line. A nil OutputMultiplexer writes
+// the output of commands as-is to os.Stdout and os.Stderr
+type OutputMultiplexer struct {
+	Mode   string
+	Stdout io.Writer
+	Stderr io.Writer
+	colors map[string]string
+	mutex  sync.Mutex
+}
+
+// NewOutputMultiplexer creates an OutputMultiplexer which writes to
+// os.Stdout and os.Stderr using the output :mode
+func NewOutputMultiplexer(mode string) *OutputMultiplexer {
+	return &OutputMultiplexer{
+		Mode:   mode,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Open returns the output of a single run of the command named :name
+// written in the output :mode, or in the Mode of the multiplexer when
+// :mode is empty. It should be closed once the command exits
+func (multiplexer *OutputMultiplexer) Open(name string, mode string) *CommandOutput {
+	if multiplexer == nil {
+		return &CommandOutput{Stdout: os.Stdout, Stderr: os.Stderr}
+	}
+	if len(mode) == 0 {
+		mode = multiplexer.Mode
+	}
+	output := &CommandOutput{Stdout: multiplexer.Stdout, Stderr: multiplexer.Stderr}
+	if mode != OutputModePrefixed && mode != OutputModeGrouped {
+		return output
+	}
+	output.multiplexer = multiplexer
+	output.mode = mode
+	output.prefix = Color(multiplexer.getColor(name), fmt.Sprintf("[%s]", name))
+	output.stdout = &commandOutputStream{output: output}
+	output.stderr = &commandOutputStream{output: output, isStderr: true}
+	output.Stdout = output.stdout
+	output.Stderr = output.stderr
+	return output
+}
+
+// getColor returns the color of the prefix of the command named :name,
+// the same command keeps its color across runs
+func (multiplexer *OutputMultiplexer) getColor(name string) string {
+	multiplexer.mutex.Lock()
+	defer multiplexer.mutex.Unlock()
+	if multiplexer.colors == nil {
+		multiplexer.colors = map[string]string{}
+	}
+	if _, ok := multiplexer.colors[name]; !ok {
+		multiplexer.colors[name] = OutputPrefixColors[len(multiplexer.colors)%len(OutputPrefixColors)]
+	}
+	return multiplexer.colors[name]
+}
+
+// write writes :data to stderr if :isStderr is true and to stdout
+// otherwise without interleaving it with the writes of other commands
+func (multiplexer *OutputMultiplexer) write(data []byte, isStderr bool) {
+	multiplexer.mutex.Lock()
+	defer multiplexer.mutex.Unlock()
+	if isStderr {
+		multiplexer.Stderr.Write(data)
+	} else {
+		multiplexer.Stdout.Write(data)
+	}
+}
+
+// CommandOutput is the output of a single run of a command, Stdout and
+// Stderr are the writers the command should write to
+type CommandOutput struct {
+	Stdout      io.Writer
+	Stderr      io.Writer
+	multiplexer *OutputMultiplexer
+	mode        string
+	prefix      string
+	stdout      *commandOutputStream
+	stderr      *commandOutputStream
+	block       []byte
+	mutex       sync.Mutex
+}
+
+// Close writes the last lines of the command which didn't end with a
+// newline and, in the grouped mode, the block of its remaining lines
+func (output *CommandOutput) Close() {
+	if output == nil || output.multiplexer == nil {
+		return
+	}
+	output.stdout.flush()
+	output.stderr.flush()
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	if len(output.block) > 0 {
+		output.multiplexer.write(output.block, false)
+		output.block = nil
+	}
+}
+
+// writeLine writes a single :line of the command with its prefix, the
+// grouped mode writes stderr lines to stdout to keep them in order
+func (output *CommandOutput) writeLine(line []byte, isStderr bool) {
+	marker := OutputStdoutMarker
+	if isStderr {
+		marker = Color("red", OutputStderrMarker)
+	}
+	formatted := []byte(fmt.Sprintf("%s %s %s\n", output.prefix, marker, bytes.TrimSuffix(line, []byte("\r"))))
+	if output.mode == OutputModeGrouped {
+		output.mutex.Lock()
+		defer output.mutex.Unlock()
+		output.block = append(output.block, formatted...)
+		return
+	}
+	output.multiplexer.write(formatted, isStderr)
+}
+
+// commandOutputStream splits what a command writes to stdout or
+// stderr into lines for its CommandOutput
+type commandOutputStream struct {
+	output   *CommandOutput
+	isStderr bool
+	partial  []byte
+}
+
+// Write writes the complete lines in :data, holding on to the rest
+// until the line is completed by a later write or the stream is flushed
+func (stream *commandOutputStream) Write(data []byte) (int, error) {
+	stream.partial = append(stream.partial, data...)
+	for {
+		index := bytes.IndexByte(stream.partial, '\n')
+		if index < 0 {
+			break
+		}
+		stream.output.writeLine(stream.partial[:index], stream.isStderr)
+		stream.partial = stream.partial[index+1:]
+	}
+	return len(data), nil
+}
+
+// flush writes the line which wasn't completed by the command
+func (stream *commandOutputStream) flush() {
+	if len(stream.partial) > 0 {
+		stream.output.writeLine(stream.partial, stream.isStderr)
+		stream.partial = nil
+	}
+}
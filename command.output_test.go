@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandOutputTestSuite struct {
+	suite.Suite
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+func TestCommandOutput(t *testing.T) {
+	suite.Run(t, new(CommandOutputTestSuite))
+}
+
+func (s *CommandOutputTestSuite) SetupTest() {
+	s.stdout.Reset()
+	s.stderr.Reset()
+}
+
+func (s *CommandOutputTestSuite) newMultiplexer(mode string) *OutputMultiplexer {
+	multiplexer := NewOutputMultiplexer(mode)
+	multiplexer.Stdout = &s.stdout
+	multiplexer.Stderr = &s.stderr
+	return multiplexer
+}
+
+func (s *CommandOutputTestSuite) Test_validateOutputMode() {
+	t := s.T()
+	assert.Nil(t, validateOutputMode(OutputModeRaw))
+	assert.Nil(t, validateOutputMode(OutputModePrefixed))
+	assert.Nil(t, validateOutputMode(OutputModeGrouped))
+	err := validateOutputMode("fancy")
+	assert.NotNil(t, err)
+	assert.Equal(t, "should be one of 'raw', 'prefixed' or 'grouped'", err.Error())
+}
+
+func (s *CommandOutputTestSuite) TestOpen_withNilMultiplexer() {
+	t := s.T()
+	var multiplexer *OutputMultiplexer
+	output := multiplexer.Open("api", "")
+	assert.Equal(t, os.Stdout, output.Stdout)
+	assert.Equal(t, os.Stderr, output.Stderr)
+	output.Close()
+}
+
+func (s *CommandOutputTestSuite) TestOpen_raw() {
+	t := s.T()
+	output := s.newMultiplexer(OutputModeRaw).Open("api", "")
+	fmt.Fprint(output.Stdout, "hello ")
+	fmt.Fprint(output.Stderr, "oops\n")
+	fmt.Fprint(output.Stdout, "world\n")
+	output.Close()
+	assert.Equal(t, "hello world\n", s.stdout.String())
+	assert.Equal(t, "oops\n", s.stderr.String())
+}
+
+func (s *CommandOutputTestSuite) TestOpen_prefixed() {
+	t := s.T()
+	multiplexer := s.newMultiplexer(OutputModePrefixed)
+	api := multiplexer.Open("api", "")
+	worker := multiplexer.Open("worker", "")
+	fmt.Fprint(api.Stdout, "listening")
+	fmt.Fprint(worker.Stdout, "polling\n")
+	fmt.Fprint(api.Stdout, " on :8080\r\nstill ")
+	fmt.Fprint(worker.Stderr, "no jobs\n")
+	api.Close()
+	worker.Close()
+	apiPrefix := Color("cyan", "[api]")
+	workerPrefix := Color("yellow", "[worker]")
+	assert.Equal(t, fmt.Sprintf(
+		"%s | polling\n%s | listening on :8080\n%s | still \n",
+		workerPrefix, apiPrefix, apiPrefix,
+	), s.stdout.String())
+	assert.Equal(t, fmt.Sprintf("%s %s no jobs\n", workerPrefix, Color("red", "!")), s.stderr.String())
+}
+
+func (s *CommandOutputTestSuite) TestOpen_grouped() {
+	t := s.T()
+	multiplexer := s.newMultiplexer(OutputModeGrouped)
+	api := multiplexer.Open("api", "")
+	worker := multiplexer.Open("worker", "")
+	fmt.Fprint(api.Stdout, "a1\n")
+	fmt.Fprint(worker.Stdout, "w1\n")
+	fmt.Fprint(api.Stderr, "a2\n")
+	fmt.Fprint(worker.Stdout, "w2\n")
+	assert.Empty(t, s.stdout.String())
+	worker.Close()
+	api.Close()
+	apiPrefix := Color("cyan", "[api]")
+	workerPrefix := Color("yellow", "[worker]")
+	assert.Equal(t, fmt.Sprintf(
+		"%s | w1\n%s | w2\n%s | a1\n%s %s a2\n",
+		workerPrefix, workerPrefix, apiPrefix, apiPrefix, Color("red", "!"),
+	), s.stdout.String())
+	assert.Empty(t, s.stderr.String())
+}
+
+func (s *CommandOutputTestSuite) TestOpen_withMode() {
+	t := s.T()
+	var stdout lockedBuffer
+	multiplexer := NewOutputMultiplexer(OutputModeGrouped)
+	multiplexer.Stdout = &stdout
+	build := multiplexer.Open("build", "")
+	app := multiplexer.Open("app", OutputModePrefixed)
+	fmt.Fprint(build.Stdout, "compiling\n")
+	fmt.Fprint(app.Stdout, "listening\n")
+	appLine := fmt.Sprintf("%s | listening\n", Color("yellow", "[app]"))
+	assert.Equal(t, appLine, stdout.String())
+	build.Close()
+	assert.Equal(t, appLine+fmt.Sprintf("%s | compiling\n", Color("cyan", "[build]")), stdout.String())
+}
+
+func (s *CommandOutputTestSuite) TestOpen_withRawModeWritesPrompts() {
+	t := s.T()
+	var stdout lockedBuffer
+	multiplexer := NewOutputMultiplexer(OutputModeGrouped)
+	multiplexer.Stdout = &stdout
+	app := multiplexer.Open("app", OutputModeRaw)
+	fmt.Fprint(app.Stdout, "Enter name: ")
+	assert.Equal(t, "Enter name: ", stdout.String())
+	app.Close()
+	assert.Equal(t, "Enter name: ", stdout.String())
+}
+
+func (s *CommandOutputTestSuite) TestOpen_keepsColorsAcrossRuns() {
+	t := s.T()
+	multiplexer := s.newMultiplexer(OutputModePrefixed)
+	multiplexer.Open("api", "").Close()
+	multiplexer.Open("worker", "").Close()
+	fmt.Fprint(multiplexer.Open("api", "").Stdout, "restarted\n")
+	assert.Equal(t, fmt.Sprintf("%s | restarted\n", Color("cyan", "[api]")), s.stdout.String())
+}
+
+func (s *CommandOutputTestSuite) TestOpen_doesNotInterleaveLines() {
+	t := s.T()
+	multiplexer := s.newMultiplexer(OutputModePrefixed)
+	var waitGroup sync.WaitGroup
+	for _, name := range []string{"a", "b", "c"} {
+		waitGroup.Add(1)
+		go func(output *CommandOutput) {
+			defer waitGroup.Done()
+			for i := 0; i < 100; i++ {
+				fmt.Fprint(output.Stdout, "some ")
+				fmt.Fprint(output.Stdout, "line\n")
+			}
+			output.Close()
+		}(multiplexer.Open(name, ""))
+	}
+	waitGroup.Wait()
+	lines := bytes.Split(bytes.TrimSuffix(s.stdout.Bytes(), []byte("\n")), []byte("\n"))
+	assert.Len(t, lines, 300)
+	for _, line := range lines {
+		assert.Regexp(t, `^\033\[\033\[\d+m\[[abc]\]\033\[0m \| some line$`, string(line))
+	}
+}
@@ -0,0 +1,60 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup starts :cmd in a process group of its own so that
+// the processes it spawns can be stopped along with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup sends :signal to the process group led by :process
+func signalProcessGroup(process *os.Process, signal syscall.Signal) error {
+	if process == nil || process.Pid <= 0 {
+		return errors.New("process has not started")
+	}
+	if err := syscall.Kill(-process.Pid, signal); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
+
+// isProcessGroupRunning checks whether any process in the process
+// group led by :process is still running
+func isProcessGroupRunning(process *os.Process) bool {
+	if process == nil || process.Pid <= 0 || syscall.Kill(-process.Pid, 0) != nil {
+		return false
+	}
+	// zombies remain in the process group until they're reaped, which
+	// never happens when godev is the init process of a container
+	statPaths, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil || len(statPaths) == 0 {
+		return true
+	}
+	processGroupID := strconv.Itoa(process.Pid)
+	for _, statPath := range statPaths {
+		stat, err := ioutil.ReadFile(statPath)
+		if err != nil {
+			continue
+		}
+		// fields following the parenthesised name are state, ppid and pgrp
+		fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+		if len(fields) >= 3 && fields[2] == processGroupID && fields[0] != "Z" {
+			return true
+		}
+	}
+	return false
+}
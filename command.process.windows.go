@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup is a no-op as process groups aren't supported
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup kills :process as it can't be signalled otherwise
+func signalProcessGroup(process *os.Process, signal syscall.Signal) error {
+	if process == nil {
+		return errors.New("process has not started")
+	}
+	return process.Kill()
+}
+
+// isProcessGroupRunning is false once :process has exited as process
+// groups aren't supported
+func isProcessGroupRunning(process *os.Process) bool {
+	return false
+}
@@ -0,0 +1,85 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// CommandPTYDrainTimeout is how long the output of a pseudo-terminal is
+// read for after its command exits, processes which the command left
+// running can keep it open
+const CommandPTYDrainTimeout = time.Second
+
+// CommandPTYEndOfInput is written to a pseudo-terminal to let its
+// command know that the input has ended, as ctrl+d would
+const CommandPTYEndOfInput = 0x04
+
+// CommandPTYDefaultSize is the size of pseudo-terminals when godev
+// isn't attached to a terminal itself
+var CommandPTYDefaultSize = &pty.Winsize{Rows: 24, Cols: 80}
+
+// runWithPTY runs :cmd attached to a pseudo-terminal and writes its
+// output to the stdout of :cmd, the input of :stdin is forwarded to it
+// when :stdin isn't nil and it is resized along with godev's terminal.
+// The process leads a session of its own which makes it the leader of
+// its process group as with setProcessGroup, :started is called once it
+// has started
+func runWithPTY(cmd *exec.Cmd, stdin *StdinForwarder, logger *Logger, started func()) error {
+	output := cmd.Stdout
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = nil, nil, nil
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	terminal, err := pty.StartWithAttrs(cmd, getTerminalSize(), cmd.SysProcAttr)
+	if err != nil {
+		return err
+	}
+	defer terminal.Close()
+	started()
+	stdin.Attach(terminal, func() { terminal.Write([]byte{CommandPTYEndOfInput}) })
+	defer stdin.Detach(terminal)
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	defer signal.Stop(resized)
+	copied := make(chan bool)
+	go func() {
+		io.Copy(output, terminal)
+		close(copied)
+	}()
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	for {
+		select {
+		case <-resized:
+			if err := pty.Setsize(terminal, getTerminalSize()); err != nil {
+				logger.Tracef("unable to resize the pseudo-terminal of pid %v: %s", cmd.Process.Pid, err)
+			}
+		case err := <-exited:
+			select {
+			case <-copied:
+			case <-time.After(CommandPTYDrainTimeout):
+			}
+			return err
+		}
+	}
+}
+
+// getTerminalSize returns the size of the terminal godev is attached
+// to, or the CommandPTYDefaultSize if it isn't attached to one
+func getTerminalSize() *pty.Winsize {
+	for _, file := range []*os.File{os.Stdin, os.Stdout, os.Stderr} {
+		if size, err := pty.GetsizeFull(file); err == nil && size.Rows > 0 && size.Cols > 0 {
+			return size
+		}
+	}
+	return CommandPTYDefaultSize
+}
@@ -0,0 +1,81 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandPTYTestSuite struct {
+	suite.Suite
+	logs lockedBuffer
+}
+
+func TestCommandPTY(t *testing.T) {
+	suite.Run(t, new(CommandPTYTestSuite))
+}
+
+func (s *CommandPTYTestSuite) TestRun_withPTY() {
+	t := s.T()
+	var output bytes.Buffer
+	command := mockCommand("sh", []string{"-c", "[ -t 0 ] && [ -t 1 ] && [ -t 2 ] && echo terminal; stty size; echo oops >&2"}, &s.logs)
+	command.config.PTY = true
+	command.output = &output
+	assert.Nil(t, runCommand(command))
+	size := getTerminalSize()
+	assert.Equal(t, fmt.Sprintf("terminal\r\n%v %v\r\noops\r\n", size.Rows, size.Cols), output.String())
+}
+
+func (s *CommandPTYTestSuite) TestRun_withPTYAndStdin() {
+	t := s.T()
+	input, writer := io.Pipe()
+	forwarder := NewStdinForwarder(input, "trace")
+	go forwarder.Forward()
+	var output lockedBuffer
+	command := mockCommand("sh", []string{"-c", "read line; echo got $line"}, &s.logs)
+	command.config.PTY = true
+	command.stdin = forwarder
+	command.output = &output
+	exited := make(chan error, 1)
+	go func() {
+		exited <- runCommand(command)
+	}()
+	assert.True(t, waitFor(func() bool { return forwarder.getTarget() != nil }))
+	writer.Write([]byte("hello\n"))
+	assert.Nil(t, <-exited)
+	assert.Contains(t, output.String(), "got hello\r\n")
+}
+
+func (s *CommandPTYTestSuite) TestRun_withPTYAndFailure() {
+	t := s.T()
+	command := mockCommand("sh", []string{"-c", "exit 3"}, &s.logs)
+	command.config.PTY = true
+	err := runCommand(command)
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, newCommandResult(command, err, false).ExitCode)
+}
+
+func (s *CommandPTYTestSuite) Test_stopProcess_withPTYStopsProcessGroup() {
+	t := s.T()
+	command := mockCommand("sh", []string{"-c", "sleep 10 & sleep 10"}, &s.logs)
+	command.config.PTY = true
+	command.config.StopSignals, _ = parseStopSignals("SIGTERM")
+	command.handleInitialisation()
+	go command.handleStart()
+	<-command.spawned
+	time.Sleep(100 * time.Millisecond)
+	processGroupID, err := syscall.Getpgid(command.cmd.Process.Pid)
+	assert.Nil(t, err)
+	assert.Equal(t, command.cmd.Process.Pid, processGroupID)
+	assert.Nil(t, command.stopProcess(syscall.SIGTERM))
+	assert.False(t, isProcessGroupRunning(command.cmd.Process))
+}
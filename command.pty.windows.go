@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os/exec"
+)
+
+// runWithPTY runs :cmd without a pseudo-terminal as they aren't
+// supported on windows, the input of :stdin is still forwarded to it
+func runWithPTY(cmd *exec.Cmd, stdin *StdinForwarder, logger *Logger, started func()) error {
+	logger.Warnf("pseudo-terminals are not supported on windows, running '%s' without one", cmd.Path)
+	return runWithStdin(cmd, stdin, started)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	shellquote "github.com/kballard/go-shellquote"
+)
+
+// CommandResult describes how a command in a pipeline run exited and
+// how many times it was attempted
+type CommandResult struct {
+	Attempts        int
+	CommandID       string
+	CommandLine     string
+	ContinueOnError bool
+	Error           error
+	ExitCode        int
+	Terminated      bool
+}
+
+// newCommandResult creates the result of :command exiting with :err,
+// the exit code is -1 if the command didn't exit by itself or its exit
+// status can't be read on this platform
+func newCommandResult(command *Command, err error, terminated bool) *CommandResult {
+	result := &CommandResult{
+		Attempts:        command.attempts,
+		CommandID:       command.GetID(),
+		CommandLine:     shellquote.Join(append([]string{command.config.Application}, command.config.Arguments...)...),
+		ContinueOnError: command.config.ContinueOnError,
+		Error:           err,
+		Terminated:      terminated,
+	}
+	if err != nil {
+		result.ExitCode = -1
+	}
+	if exitError, ok := err.(*exec.ExitError); ok && exitError.ProcessState != nil {
+		if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
+			result.ExitCode = status.ExitStatus()
+		}
+	}
+	return result
+}
+
+// IsFailure checks whether the result should stop the pipeline
+func (result *CommandResult) IsFailure() bool {
+	return result.Error != nil && !result.Terminated && !result.ContinueOnError
+}
+
+// String describes the result for the run summary
+func (result *CommandResult) String() string {
+	outcome := "succeeded"
+	switch {
+	case result.Terminated:
+		outcome = "was terminated"
+	case result.Error == nil:
+	case result.ExitCode >= 0:
+		outcome = fmt.Sprintf("failed with exit code %v", result.ExitCode)
+	default:
+		outcome = fmt.Sprintf("failed: %s", result.Error)
+	}
+	if result.Attempts > 1 {
+		outcome += fmt.Sprintf(" after %v attempts", result.Attempts)
+	}
+	if result.Error != nil && result.ContinueOnError && !result.Terminated {
+		outcome += " (continue_on_error, continuing)"
+	}
+	return fmt.Sprintf("command[%s] '%s' %s", result.CommandID, result.CommandLine, outcome)
+}
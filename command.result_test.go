@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandResultTestSuite struct {
+	suite.Suite
+	command *Command
+}
+
+func TestCommandResultTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandResultTestSuite))
+}
+
+func (s *CommandResultTestSuite) SetupTest() {
+	var logs bytes.Buffer
+	s.command = mockCommand("go", []string{"build", "./..."}, &logs)
+}
+
+func (s *CommandResultTestSuite) Test_newCommandResult_withExitError() {
+	t := s.T()
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	result := newCommandResult(s.command, err, false)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.True(t, result.IsFailure())
+	assert.Equal(t, "command[go[build ./...]] 'go build ./...' failed with exit code 3", result.String())
+}
+
+func (s *CommandResultTestSuite) Test_newCommandResult_withoutExitStatus() {
+	t := s.T()
+	result := newCommandResult(s.command, &exec.ExitError{}, false)
+	assert.Equal(t, -1, result.ExitCode)
+	assert.True(t, result.IsFailure())
+}
+
+func (s *CommandResultTestSuite) Test_newCommandResult_withContinueOnError() {
+	t := s.T()
+	s.command.config.ContinueOnError = true
+	result := newCommandResult(s.command, errors.New("not found"), false)
+	assert.Equal(t, -1, result.ExitCode)
+	assert.False(t, result.IsFailure())
+	assert.Contains(t, result.String(), "failed: not found (continue_on_error, continuing)")
+}
+
+func (s *CommandResultTestSuite) Test_newCommandResult_whenTerminated() {
+	t := s.T()
+	result := newCommandResult(s.command, errors.New("interrupt"), true)
+	assert.False(t, result.IsFailure())
+	assert.Contains(t, result.String(), "was terminated")
+}
+
+func (s *CommandResultTestSuite) Test_newCommandResult_withoutError() {
+	t := s.T()
+	result := newCommandResult(s.command, nil, false)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.False(t, result.IsFailure())
+	assert.Contains(t, result.String(), "succeeded")
+}
+
+func (s *CommandResultTestSuite) Test_newCommandResult_withTimeoutAndRetries() {
+	t := s.T()
+	s.command.attempts = 3
+	result := newCommandResult(s.command, &CommandTimeoutError{Timeout: time.Minute}, false)
+	assert.True(t, result.IsFailure())
+	assert.Equal(t, "command[go[build ./...]] 'go build ./...' failed: timed out after 1m0s after 3 attempts", result.String())
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// StdinForwarderBufferSize is the most that is read from the input of
+// a StdinForwarder at once
+const StdinForwarderBufferSize = 4096
+
+// StdinForwarder forwards what is read from its input to the stdin of
+// the process which is currently attached to it. Input read while no
+// process is attached is dropped so that a restarted process doesn't
+// receive what was meant for the one before it
+type StdinForwarder struct {
+	input  io.Reader
+	target *stdinTarget
+	ended  bool
+	mutex  sync.Mutex
+	logger *Logger
+}
+
+// stdinTarget is the stdin of a process attached to a StdinForwarder,
+// end is called to let the process know that the input has ended
+type stdinTarget struct {
+	writer io.Writer
+	end    func()
+}
+
+// NewStdinForwarder creates a StdinForwarder which forwards :input,
+// it only starts reading :input once Forward is called
+func NewStdinForwarder(input io.Reader, logLevel LogLevel) *StdinForwarder {
+	return &StdinForwarder{
+		input:  input,
+		logger: InitLogger(&LoggerConfig{Name: "stdin", Format: "production", Level: logLevel}),
+	}
+}
+
+// Forward reads the input until it ends and writes it to the attached
+// process, it blocks and should only be called once. The end of input
+// from a terminal (eg. ctrl+d) is passed on to the attached process and
+// reading continues, other inputs also end for processes attached later
+func (forwarder *StdinForwarder) Forward() {
+	buffer := make([]byte, StdinForwarderBufferSize)
+	for {
+		count, err := forwarder.input.Read(buffer)
+		if count > 0 {
+			if target := forwarder.getTarget(); target != nil {
+				target.writer.Write(buffer[:count])
+			} else {
+				forwarder.logger.Tracef("dropped %v byte(s) of input, no process is attached", count)
+			}
+		}
+		if err == io.EOF {
+			forwarder.endTarget()
+			if isTerminal(forwarder.input) {
+				continue
+			}
+			forwarder.mutex.Lock()
+			forwarder.ended = true
+			forwarder.mutex.Unlock()
+			forwarder.logger.Debug("input has ended, processes will receive no further input")
+			return
+		} else if err != nil {
+			forwarder.logger.Warnf("stopped forwarding input: %s", err)
+			return
+		}
+	}
+}
+
+// Attach forwards the input to :writer from now on, :end is called
+// when the input ends. A nil StdinForwarder doesn't attach anything
+func (forwarder *StdinForwarder) Attach(writer io.Writer, end func()) {
+	if forwarder == nil {
+		return
+	}
+	forwarder.mutex.Lock()
+	defer forwarder.mutex.Unlock()
+	if forwarder.ended {
+		end()
+		return
+	}
+	forwarder.target = &stdinTarget{writer: writer, end: end}
+}
+
+// Detach stops forwarding the input to :writer if it's still attached,
+// a process attached after it is left attached
+func (forwarder *StdinForwarder) Detach(writer io.Writer) {
+	if forwarder == nil {
+		return
+	}
+	forwarder.mutex.Lock()
+	defer forwarder.mutex.Unlock()
+	if forwarder.target != nil && forwarder.target.writer == writer {
+		forwarder.target = nil
+	}
+}
+
+func (forwarder *StdinForwarder) getTarget() *stdinTarget {
+	forwarder.mutex.Lock()
+	defer forwarder.mutex.Unlock()
+	return forwarder.target
+}
+
+// endTarget lets the attached process know that the input has ended
+func (forwarder *StdinForwarder) endTarget() {
+	if target := forwarder.getTarget(); target != nil {
+		target.end()
+	}
+}
+
+// runWithStdin runs :cmd with the input of :stdin forwarded to it, the
+// stdin of :cmd is left unset when :stdin is nil. :started is called
+// once the process has started
+func runWithStdin(cmd *exec.Cmd, stdin *StdinForwarder, started func()) error {
+	if stdin != nil {
+		pipe, err := cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		stdin.Attach(pipe, func() { pipe.Close() })
+		defer stdin.Detach(pipe)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	started()
+	return cmd.Wait()
+}
+
+// isTerminal checks whether :input is a terminal
+func isTerminal(input io.Reader) bool {
+	file, ok := input.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
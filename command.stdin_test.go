@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandStdinTestSuite struct {
+	suite.Suite
+	logs lockedBuffer
+}
+
+func TestCommandStdin(t *testing.T) {
+	suite.Run(t, new(CommandStdinTestSuite))
+}
+
+// lockedBuffer is a bytes.Buffer which can be written to and read
+// from different goroutines
+type lockedBuffer struct {
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+func (buffer *lockedBuffer) Write(data []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.Write(data)
+}
+
+func (buffer *lockedBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.String()
+}
+
+// waitFor polls :condition until it's true or a few seconds have passed
+func waitFor(condition func() bool) bool {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *CommandStdinTestSuite) TestForward() {
+	t := s.T()
+	input, writer := io.Pipe()
+	forwarder := NewStdinForwarder(input, "trace")
+	forwarder.logger.SetOutput(&s.logs)
+	go forwarder.Forward()
+	writer.Write([]byte("dropped\n"))
+	assert.True(t, waitFor(func() bool { return strings.Contains(s.logs.String(), "dropped 8 byte(s) of input") }))
+	var first, second lockedBuffer
+	forwarder.Attach(&first, func() {})
+	writer.Write([]byte("one\n"))
+	forwarder.Attach(&second, func() {})
+	forwarder.Detach(&first)
+	writer.Write([]byte("two\n"))
+	forwarder.Detach(&second)
+	writer.Write([]byte("three\n"))
+	assert.True(t, waitFor(func() bool { return strings.Contains(s.logs.String(), "dropped 6 byte(s) of input") }))
+	assert.Equal(t, "one\n", first.String())
+	assert.Equal(t, "two\n", second.String())
+}
+
+func (s *CommandStdinTestSuite) TestForward_endOfInput() {
+	t := s.T()
+	forwarder := NewStdinForwarder(strings.NewReader("last line\n"), "trace")
+	forwarder.logger.SetOutput(&s.logs)
+	var output lockedBuffer
+	ended := 0
+	forwarder.Attach(&output, func() { ended++ })
+	forwarder.Forward()
+	assert.Equal(t, "last line\n", output.String())
+	assert.Equal(t, 1, ended)
+	forwarder.Attach(&output, func() { ended++ })
+	assert.Equal(t, 2, ended)
+}
+
+func (s *CommandStdinTestSuite) TestForward_withNilForwarder() {
+	var forwarder *StdinForwarder
+	forwarder.Attach(&bytes.Buffer{}, func() {})
+	forwarder.Detach(&bytes.Buffer{})
+	assert.Nil(s.T(), runWithStdin(exec.Command("true"), forwarder, func() {}))
+}
+
+func (s *CommandStdinTestSuite) TestRun_withStdin() {
+	t := s.T()
+	input, writer := io.Pipe()
+	forwarder := NewStdinForwarder(input, "trace")
+	go forwarder.Forward()
+	var output lockedBuffer
+	for _, name := range []string{"first", "second"} {
+		command := mockCommand("sh", []string{"-c", "read line; echo " + name + " got $line"}, &s.logs)
+		command.stdin = forwarder
+		command.output = &output
+		exited := make(chan error, 1)
+		go func() {
+			exited <- runCommand(command)
+		}()
+		assert.True(t, waitFor(func() bool { return forwarder.getTarget() != nil }))
+		writer.Write([]byte(name + " input\n"))
+		assert.Nil(t, <-exited)
+	}
+	assert.Equal(t, "first got first input\nsecond got second input\n", output.String())
+}
+
+func (s *CommandStdinTestSuite) TestRun_withStdinEnded() {
+	t := s.T()
+	forwarder := NewStdinForwarder(strings.NewReader(""), "trace")
+	forwarder.Forward()
+	var output bytes.Buffer
+	command := mockCommand("cat", []string{}, &s.logs)
+	command.stdin = forwarder
+	command.output = &output
+	assert.Nil(t, runCommand(command))
+	assert.Empty(t, output.String())
+}
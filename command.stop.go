@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// CommandStopTimeout is how long a command's process group has to go
+// away after the last of its stop signals was sent
+const CommandStopTimeout = 5 * time.Second
+
+// CommandStopPollInterval is how often a stopping command is checked on
+const CommandStopPollInterval = 50 * time.Millisecond
+
+// stopSignalNames are the signals which can be used to stop commands
+var stopSignalNames = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+// StopSignal is a signal sent to the process group of a command which
+// is being stopped, After is the time since stopping began
+type StopSignal struct {
+	Signal syscall.Signal
+	After  time.Duration
+}
+
+// StopSignals are sent in order until the process group of a command
+// is gone
+type StopSignals []StopSignal
+
+// parseStopSignals parses a comma-delimited list of signals optionally
+// followed by when they are sent (eg. "SIGINT,SIGTERM:5s,SIGKILL:10s")
+func parseStopSignals(value string) (StopSignals, error) {
+	var stopSignals StopSignals
+	for _, item := range splitNonEmpty(value, ",") {
+		stopSignal := StopSignal{}
+		name := item
+		if separatorIndex := strings.Index(item, ":"); separatorIndex >= 0 {
+			name = strings.TrimSpace(item[:separatorIndex])
+			after, err := time.ParseDuration(strings.TrimSpace(item[separatorIndex+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid delay in stop signal '%s': %s", item, err)
+			}
+			stopSignal.After = after
+		}
+		signal, ok := stopSignalNames[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+		if !ok {
+			return nil, fmt.Errorf("unknown stop signal '%s'", name)
+		}
+		stopSignal.Signal = signal
+		if len(stopSignals) > 0 && stopSignal.After < stopSignals[len(stopSignals)-1].After {
+			return nil, fmt.Errorf("stop signal '%s' should not be sent before the one preceding it", item)
+		}
+		stopSignals = append(stopSignals, stopSignal)
+	}
+	if len(stopSignals) == 0 {
+		return nil, errors.New("at least one stop signal should be specified")
+	}
+	return stopSignals, nil
+}
+
+// String returns the stop signals in the format they are parsed from
+func (stopSignals StopSignals) String() string {
+	var items []string
+	for _, stopSignal := range stopSignals {
+		item := stopSignal.Signal.String()
+		for name, signal := range stopSignalNames {
+			if signal == stopSignal.Signal {
+				item = "SIG" + name
+			}
+		}
+		if stopSignal.After > 0 {
+			item = fmt.Sprintf("%s:%s", item, stopSignal.After)
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ",")
+}
+
+// stopProcess stops the command's process group by sending it the stop
+// signals, starting with :signal if no stop signals were configured, and
+// waits for the process group to be gone. A process which is still being
+// started is stopped once it has started and one which fails to start
+// has nothing to stop
+func (command *Command) stopProcess(signal os.Signal) error {
+	stopSignals := command.config.StopSignals
+	if systemSignal, ok := signal.(syscall.Signal); ok && len(stopSignals) == 0 {
+		stopSignals = StopSignals{{Signal: systemSignal}}
+	}
+	exited := false
+	select {
+	case <-command.spawned:
+	case <-command.run:
+		select {
+		case <-command.spawned:
+			exited = true
+		default:
+			return nil
+		}
+	}
+	process := command.cmd.Process
+	startedAt := time.Now()
+	for index, stopSignal := range stopSignals {
+		command.logger.Debugf("command[%s] is being stopped with %s", command.id, stopSignal.Signal)
+		if err := signalProcessGroup(process, stopSignal.Signal); err != nil {
+			return err
+		}
+		deadline := startedAt.Add(stopSignal.After + CommandStopTimeout)
+		if index < len(stopSignals)-1 {
+			deadline = startedAt.Add(stopSignals[index+1].After)
+		}
+		for time.Now().Before(deadline) {
+			if !exited {
+				select {
+				case <-command.run:
+					exited = true
+				case <-time.After(CommandStopPollInterval):
+				}
+			} else {
+				time.Sleep(CommandStopPollInterval)
+			}
+			if exited && !isProcessGroupRunning(process) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("command[%s] is still running after %s", command.id, stopSignals)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandStopTestSuite struct {
+	suite.Suite
+	logs lockedBuffer
+}
+
+func TestCommandStop(t *testing.T) {
+	suite.Run(t, new(CommandStopTestSuite))
+}
+
+func (s *CommandStopTestSuite) startCommand(script string, stopSignals string) *Command {
+	command := mockCommand("sh", []string{"-c", script}, &s.logs)
+	command.config.StopSignals, _ = parseStopSignals(stopSignals)
+	command.handleInitialisation()
+	go command.handleStart()
+	<-command.spawned
+	// gives the shell time to set up its traps and children
+	time.Sleep(100 * time.Millisecond)
+	return command
+}
+
+func (s *CommandStopTestSuite) Test_parseStopSignals() {
+	t := s.T()
+	stopSignals, err := parseStopSignals("SIGINT, term:5s,KILL:10s")
+	assert.Nil(t, err)
+	assert.Equal(t, StopSignals{
+		{Signal: syscall.SIGINT},
+		{Signal: syscall.SIGTERM, After: 5 * time.Second},
+		{Signal: syscall.SIGKILL, After: 10 * time.Second},
+	}, stopSignals)
+	assert.Equal(t, "SIGINT,SIGTERM:5s,SIGKILL:10s", stopSignals.String())
+}
+
+func (s *CommandStopTestSuite) Test_parseStopSignals_withInvalidValues() {
+	t := s.T()
+	_, err := parseStopSignals("SIGSTOP")
+	assert.Contains(t, err.Error(), "unknown stop signal 'SIGSTOP'")
+	_, err = parseStopSignals("SIGINT:soon")
+	assert.Contains(t, err.Error(), "invalid delay in stop signal 'SIGINT:soon'")
+	_, err = parseStopSignals("SIGKILL:10s,SIGTERM:5s")
+	assert.Contains(t, err.Error(), "should not be sent before the one preceding it")
+	_, err = parseStopSignals(" , ")
+	assert.Contains(t, err.Error(), "at least one stop signal")
+}
+
+func (s *CommandStopTestSuite) Test_stopProcess_stopsProcessGroup() {
+	t := s.T()
+	// background jobs of non-interactive shells ignore SIGINT
+	command := s.startCommand("sleep 30 & sleep 30", "SIGTERM")
+	assert.Nil(t, command.stopProcess(syscall.SIGINT))
+	assert.False(t, isProcessGroupRunning(command.cmd.Process))
+}
+
+func (s *CommandStopTestSuite) Test_stopProcess_escalates() {
+	t := s.T()
+	command := s.startCommand("trap '' INT TERM; sleep 30 & wait", "SIGINT,SIGTERM:100ms,SIGKILL:200ms")
+	startedAt := time.Now()
+	assert.Nil(t, command.stopProcess(syscall.SIGINT))
+	assert.True(t, time.Since(startedAt) >= 200*time.Millisecond)
+	assert.False(t, isProcessGroupRunning(command.cmd.Process))
+	assert.Contains(t, s.logs.String(), "is being stopped with killed")
+}
+
+func (s *CommandStopTestSuite) Test_stopProcess_whileStarting() {
+	t := s.T()
+	command := mockCommand("sleep", []string{"30"}, &s.logs)
+	command.handleInitialisation()
+	stopped := make(chan error)
+	go func() { stopped <- command.stopProcess(syscall.SIGTERM) }()
+	time.Sleep(100 * time.Millisecond)
+	go command.handleStart()
+	assert.Nil(t, <-stopped)
+	assert.False(t, isProcessGroupRunning(command.cmd.Process))
+}
+
+func (s *CommandStopTestSuite) Test_stopProcess_withoutProcess() {
+	command := mockCommand("/does/not/exist", []string{}, &s.logs)
+	command.handleInitialisation()
+	go command.handleStart()
+	assert.Nil(s.T(), command.stopProcess(syscall.SIGINT))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"sync"
@@ -18,7 +19,7 @@ import (
 type CommandTestSuite struct {
 	suite.Suite
 	command    *Command
-	logs       bytes.Buffer
+	logs       lockedBuffer
 	expectedID string
 }
 
@@ -139,6 +140,23 @@ func (s *CommandTestSuite) TestSendInterrupt() {
 	wg.Wait()
 }
 
+func (s *CommandTestSuite) TestSendInterrupt_afterExit() {
+	t := s.T()
+	command := mockCommand("true", []string{}, &s.logs)
+	assert.Nil(t, runCommand(command))
+	sent := make(chan bool)
+	go func() {
+		command.SendInterrupt()
+		command.SendInterrupt()
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "interrupting a command which exited should not block")
+	}
+}
+
 func (s *CommandTestSuite) Test_handleInitialisation() {
 	t := s.T()
 	expectedDir := "/some/directory"
@@ -170,6 +188,20 @@ func (s *CommandTestSuite) Test_handleInitialisation() {
 	}
 }
 
+func (s *CommandTestSuite) Test_handleInitialisation_withoutInheritedEnvironment() {
+	cmd := &Command{
+		config: &CommandConfig{
+			Application:        "go",
+			Environment:        []string{"A=1", "A=2"},
+			EnvironmentInherit: EnvironmentInheritNone,
+		},
+		logger: s.command.logger,
+	}
+	cmd.handleInitialisation()
+	assert.Equal(s.T(), []string{"A=2"}, cmd.cmd.Env)
+	assert.Contains(s.T(), s.logs.String(), "environment:\n  A=2")
+}
+
 func (s *CommandTestSuite) Test_handleProcessExited() {
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -187,6 +219,7 @@ func (s *CommandTestSuite) Test_handleProcessExited() {
 func (s *CommandTestSuite) Test_handleProcessLifecycleCallerSaysStop() {
 	var wg sync.WaitGroup
 	s.command.cmd.Process = &os.Process{}
+	close(s.command.spawned)
 	wg.Add(1)
 	go func() {
 		select {
@@ -241,6 +274,7 @@ func (s *CommandTestSuite) Test_handleSignalReceived() {
 	sigstring := []string{"interrupt", "terminated", "killed"}
 	var wg sync.WaitGroup
 	s.command.cmd.Process = &os.Process{}
+	close(s.command.spawned)
 	for i := 0; i < len(sigcalls); i++ {
 		wg.Add(1)
 		go func(j int) {
@@ -288,3 +322,149 @@ func (s *CommandTestSuite) Test_handleStopped() {
 	s.command.handleStopped(errors.New("Test_handleStopped"))
 	wg.Wait()
 }
+
+func (s *CommandTestSuite) Test_handleInitialisation_withPipelineRun() {
+	t := s.T()
+	cmd := &Command{
+		config: &CommandConfig{
+			Application:        "echo",
+			Arguments:          []string{"run-${GODEV_RUN_ID}"},
+			Environment:        []string{"GODEV_WORK_DIR=/overridden"},
+			EnvironmentInherit: EnvironmentInheritNone,
+			GodevEnvironment:   []string{"GODEV_WORK_DIR=/work"},
+		},
+		pipelineRun: &PipelineRun{ID: 3},
+	}
+	cmd.handleInitialisation()
+	assert.Equal(t, []string{"echo", "run-3"}, cmd.cmd.Args)
+	assert.Equal(t, []string{
+		"GODEV_WORK_DIR=/overridden",
+		"GODEV_RUN_ID=3",
+		"GODEV_CHANGED_FILES=",
+		"GODEV_CHANGESET_FILE=",
+	}, cmd.cmd.Env)
+}
+
+func (s *CommandTestSuite) Test_handleInitialisation_throughShell() {
+	t := s.T()
+	var output bytes.Buffer
+	cmd := mockCommand("/bin/sh", []string{"-c", "echo $GODEV_RUN_ID | tr 7 8 && echo done"}, &s.logs)
+	cmd.config.EnvironmentInherit = EnvironmentInheritNone
+	cmd.pipelineRun = &PipelineRun{ID: 7}
+	assert.Nil(t, cmd.IsValid())
+	cmd.handleInitialisation()
+	cmd.cmd.Stdout = &output
+	assert.Nil(t, cmd.cmd.Run())
+	assert.Equal(t, "8\ndone\n", output.String())
+}
+
+func (s *CommandTestSuite) TestRun_withOutputMultiplexer() {
+	t := s.T()
+	var stdout, stderr bytes.Buffer
+	command := mockCommand("sh", []string{"-c", "echo out; echo err >&2; printf partial"}, &s.logs)
+	command.config.Name = "api"
+	command.pipelineRun = &PipelineRun{ID: 1}
+	command.multiplexer = &OutputMultiplexer{Mode: OutputModeGrouped, Stdout: &stdout, Stderr: &stderr}
+	assert.Nil(t, runCommand(command))
+	prefix := Color("cyan", "[api]")
+	assert.Contains(t, stdout.String(), prefix+" | out\n")
+	assert.Contains(t, stdout.String(), prefix+" "+Color("red", "!")+" err\n")
+	assert.Contains(t, stdout.String(), prefix+" | partial\n")
+	assert.Empty(t, stderr.String())
+}
+
+func (s *CommandTestSuite) TestRun_withLogPath() {
+	t := s.T()
+	directory, err := ioutil.TempDir("", "godev-command-logs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	var output bytes.Buffer
+	command := mockCommand("sh", []string{"-c", "echo out; echo err >&2"}, &s.logs)
+	command.logPath = path.Join(directory, "1", "build", "a1b2c3.log")
+	command.output = &output
+	assert.Nil(t, runCommand(command))
+	assert.Nil(t, runCommand(command))
+	logged, err := ioutil.ReadFile(command.logPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 2*len("out\nerr\n"), len(logged))
+	assert.Equal(t, string(logged), output.String())
+}
+
+func (s *CommandTestSuite) TestRun_withUnwritableLogPath() {
+	t := s.T()
+	var output bytes.Buffer
+	command := mockCommand("echo", []string{"still runs"}, &s.logs)
+	command.logPath = "/dev/null/a1b2c3.log"
+	command.output = &output
+	assert.Nil(t, runCommand(command))
+	assert.Equal(t, "still runs\n", output.String())
+	assert.Contains(t, s.logs.String(), "output is not being logged to a file")
+}
+
+func (s *CommandTestSuite) TestGetName() {
+	t := s.T()
+	command := mockCommand("echo", []string{"1"}, &s.logs)
+	assert.Equal(t, "echo[1]", command.GetName())
+	command.config.Name = "greeter"
+	assert.Equal(t, "greeter", command.GetName())
+}
+
+func (s *CommandTestSuite) TestRun_withTimeout() {
+	t := s.T()
+	command := mockCommand("sleep", []string{"10"}, &s.logs)
+	command.config.Timeout = 200 * time.Millisecond
+	started := time.Now()
+	err := runCommand(command)
+	assert.Equal(t, &CommandTimeoutError{Timeout: 200 * time.Millisecond}, err)
+	assert.Equal(t, "timed out after 200ms", err.Error())
+	assert.True(t, time.Since(started) < 5*time.Second)
+	assert.Contains(t, s.logs.String(), "timed out after 200ms, stopping it")
+}
+
+func (s *CommandTestSuite) TestRun_withRetries() {
+	t := s.T()
+	directory := path.Join(os.TempDir(), fmt.Sprintf("godev-retries-%v", time.Now().UnixNano()))
+	defer os.RemoveAll(directory)
+	// fails on the first attempt and succeeds on the second
+	command := mockCommand("sh", []string{"-c", fmt.Sprintf("mkdir %s", directory)}, &s.logs)
+	command.config.Retries = 2
+	assert.Nil(t, runCommand(command))
+	assert.Equal(t, 1, command.attempts)
+	assert.NotNil(t, runCommand(command))
+	assert.Equal(t, 3, command.attempts)
+	assert.Contains(t, s.logs.String(), "retrying in 1s (retry 1 of 2)")
+	assert.Contains(t, s.logs.String(), "retrying in 2s (retry 2 of 2)")
+}
+
+func (s *CommandTestSuite) TestRun_stopsRetryingWhenSignalled() {
+	t := s.T()
+	command := mockCommand("false", []string{}, &s.logs)
+	command.config.Retries = 5
+	go func() {
+		for !command.IsRunning() || command.attempts < 1 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		command.SendInterrupt()
+	}()
+	started := time.Now()
+	assert.Equal(t, "interrupt", runCommand(command).Error())
+	assert.True(t, time.Since(started) < 5*time.Second)
+}
+
+// runCommand runs :command and returns the status it reports
+func runCommand(command *Command) error {
+	status := command.GetStatus()
+	reported := make(chan error)
+	go func() {
+		for {
+			select {
+			case err := <-*status:
+				reported <- err
+				return
+			default:
+			}
+		}
+	}()
+	go command.Run()
+	return <-reported
+}
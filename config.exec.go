@@ -0,0 +1,571 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigExecGroups are the execution groups as specified in a
+// configuration layer
+type ConfigExecGroups []*ConfigExecGroup
+
+// ConfigExecGroup is an execution group which is either a string of
+// commands delimited by --exec-delim or, in the config file, a mapping
+// which can specify the directory, environment and arguments for all
+// of its commands. Nil arguments fall back to --args for the last group
+// while empty arguments don't pass any. A group depends on the group
+// before it unless it specifies DependsOn, groups are referred to by
+// their Name which defaults to their position starting from 1. Groups
+// with WhenChanged only run when a changed file matches one of them.
+// Groups with Inputs are skipped if the files matching them are the
+// same as when the group last succeeded and its Outputs exist. Groups
+// which run in the Background are done once they pass their Ready check.
+// Groups with MaxParallel run at most that many commands at once
+type ConfigExecGroup struct {
+	Name        string               `yaml:"name" toml:"name"`
+	DependsOn   []string             `yaml:"depends_on" toml:"depends_on"`
+	WhenChanged []*ChangePattern     `yaml:"when_changed" toml:"when_changed"`
+	Inputs      []string             `yaml:"inputs" toml:"inputs"`
+	Outputs     []string             `yaml:"outputs" toml:"outputs"`
+	Background  bool                 `yaml:"background" toml:"background"`
+	Ready       *ConfigReadyCheck    `yaml:"ready" toml:"ready"`
+	MaxParallel int                  `yaml:"max_parallel" toml:"max_parallel"`
+	Commands    []*ConfigExecCommand `yaml:"commands" toml:"commands"`
+	Directory   string               `yaml:"dir" toml:"dir"`
+	EnvVars     []string             `yaml:"env" toml:"env"`
+	Arguments   []string             `yaml:"args" toml:"args"`
+	delimited   string
+}
+
+// ConfigReadyCheck is the readiness check of a ConfigExecGroup which
+// runs in the background, it passes when all of the checks it specifies
+// pass before the Timeout
+type ConfigReadyCheck struct {
+	TCP     string `yaml:"tcp" toml:"tcp"`
+	HTTP    string `yaml:"http" toml:"http"`
+	Log     string `yaml:"log" toml:"log"`
+	File    string `yaml:"file" toml:"file"`
+	Timeout string `yaml:"timeout" toml:"timeout"`
+}
+
+// ConfigExecCommand is a single command in a ConfigExecGroup, its
+// directory, environment and arguments take precedence over those
+// of its group and its Name prefixes its output. A failing command
+// stops the pipeline unless it sets ContinueOnError, it is stopped and
+// fails when it runs for longer than its Timeout and is run again up
+// to Retries times when it fails
+type ConfigExecCommand struct {
+	Run             string   `yaml:"run" toml:"run"`
+	Name            string   `yaml:"name" toml:"name"`
+	Directory       string   `yaml:"dir" toml:"dir"`
+	EnvVars         []string `yaml:"env" toml:"env"`
+	Arguments       []string `yaml:"args" toml:"args"`
+	ContinueOnError bool     `yaml:"continue_on_error" toml:"continue_on_error"`
+	Timeout         string   `yaml:"timeout" toml:"timeout"`
+	Retries         int      `yaml:"retries" toml:"retries"`
+}
+
+// getExecGroups returns the execution groups to run, ExecGroupConfigs
+// are only set when the groups came from a configuration layer
+func (config *Config) getExecGroups() ConfigExecGroups {
+	if len(config.ExecGroupConfigs) > 0 {
+		return config.ExecGroupConfigs
+	}
+	return newConfigExecGroups(config.ExecGroups)
+}
+
+// getExecGroupDependencies returns the names of the execution groups and
+// the indices of the groups each of them depends on, unknown names,
+// duplicate names and cyclic dependencies are reported as errors
+func (config *Config) getExecGroupDependencies() ([]string, [][]int, error) {
+	var problems ConfigProblems
+	execGroups := config.getExecGroups()
+	names := make([]string, len(execGroups))
+	indices := map[string]int{}
+	for index, execGroup := range execGroups {
+		names[index] = execGroup.Name
+		if len(names[index]) == 0 {
+			names[index] = strconv.Itoa(index + 1)
+		}
+		if _, exists := indices[names[index]]; exists {
+			problems = append(problems, fmt.Errorf("execution group %v has the same name as another group ('%s')", index+1, names[index]))
+			continue
+		}
+		indices[names[index]] = index
+	}
+	dependencies := make([][]int, len(execGroups))
+	for index, execGroup := range execGroups {
+		if execGroup.DependsOn == nil {
+			if index > 0 {
+				dependencies[index] = []int{index - 1}
+			}
+			continue
+		}
+		dependencies[index] = []int{}
+		for _, name := range execGroup.DependsOn {
+			dependencyIndex, exists := indices[name]
+			if !exists {
+				problems = append(problems, fmt.Errorf("execution group '%s' depends on '%s' which does not exist", names[index], name))
+				continue
+			}
+			dependencies[index] = append(dependencies[index], dependencyIndex)
+		}
+	}
+	if cycle := findDependencyCycle(dependencies); cycle != nil {
+		var cycleNames []string
+		for _, index := range cycle {
+			cycleNames = append(cycleNames, names[index])
+		}
+		problems = append(problems, fmt.Errorf("execution groups have a cyclic dependency: %s", strings.Join(cycleNames, " > ")))
+	}
+	if len(problems) > 0 {
+		return names, dependencies, problems
+	}
+	return names, dependencies, nil
+}
+
+// findDependencyCycle returns the indices forming a cycle in
+// :dependencies with the first index repeated at the end, or nil
+// if there isn't a cycle
+func findDependencyCycle(dependencies [][]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make([]int, len(dependencies))
+	var path []int
+	var visit func(index int) []int
+	visit = func(index int) []int {
+		states[index] = visiting
+		path = append(path, index)
+		for _, dependency := range dependencies[index] {
+			if states[dependency] == visiting {
+				for start, pathIndex := range path {
+					if pathIndex == dependency {
+						return append(append([]int{}, path[start:]...), dependency)
+					}
+				}
+			} else if states[dependency] == unvisited {
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[index] = visited
+		return nil
+	}
+	for index := range dependencies {
+		if states[index] == unvisited {
+			if cycle := visit(index); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// newConfigExecGroups creates execution groups from delimited strings
+// such as those from --exec
+func newConfigExecGroups(execGroups []string) ConfigExecGroups {
+	groups := ConfigExecGroups{}
+	for _, execGroup := range execGroups {
+		groups = append(groups, &ConfigExecGroup{delimited: execGroup})
+	}
+	return groups
+}
+
+// Strings returns the execution groups as they are displayed
+func (groups ConfigExecGroups) Strings() []string {
+	execGroups := []string{}
+	for _, group := range groups {
+		execGroups = append(execGroups, group.String())
+	}
+	return execGroups
+}
+
+// String returns the delimited string the group was specified with,
+// or its commands joined by ', ' if it was specified as a mapping
+func (group *ConfigExecGroup) String() string {
+	if len(group.Commands) == 0 {
+		return group.delimited
+	}
+	var commands []string
+	for _, command := range group.Commands {
+		commands = append(commands, command.Run)
+	}
+	return strings.Join(commands, ", ")
+}
+
+// getCommands returns the commands in the group, splitting the
+// delimited string with :delimiter if the group was specified as one
+func (group *ConfigExecGroup) getCommands(delimiter string) []*ConfigExecCommand {
+	if len(group.Commands) > 0 {
+		return group.Commands
+	}
+	var commands []*ConfigExecCommand
+	for _, command := range strings.Split(group.delimited, delimiter) {
+		commands = append(commands, &ConfigExecCommand{Run: command})
+	}
+	return commands
+}
+
+// UnmarshalYAML allows a group to be specified as a string or mapping
+func (group *ConfigExecGroup) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&group.delimited); err == nil {
+		return nil
+	}
+	type configExecGroup ConfigExecGroup
+	if err := unmarshal((*configExecGroup)(group)); err != nil {
+		return err
+	}
+	return group.validate()
+}
+
+// UnmarshalTOML allows a group to be specified as a string or table
+func (group *ConfigExecGroup) UnmarshalTOML(data interface{}) error {
+	if delimited, ok := data.(string); ok {
+		group.delimited = delimited
+		return nil
+	}
+	table, err := newConfigExecTable(data, "name", "depends_on", "when_changed", "inputs", "outputs", "background", "ready", "max_parallel", "commands", "dir", "env", "args")
+	if err != nil {
+		return err
+	}
+	whenChanged, ok := table["when_changed"].([]interface{})
+	if _, exists := table["when_changed"]; exists && !ok {
+		return fmt.Errorf("'when_changed' should be a list")
+	}
+	for _, data := range whenChanged {
+		pattern := &ChangePattern{}
+		if err := pattern.UnmarshalTOML(data); err != nil {
+			return err
+		}
+		group.WhenChanged = append(group.WhenChanged, pattern)
+	}
+	if group.Name, err = table.getString("name"); err != nil {
+		return err
+	} else if group.DependsOn, err = table.getStrings("depends_on"); err != nil {
+		return err
+	} else if group.Inputs, err = table.getStrings("inputs"); err != nil {
+		return err
+	} else if group.Outputs, err = table.getStrings("outputs"); err != nil {
+		return err
+	} else if group.Background, err = table.getBool("background"); err != nil {
+		return err
+	} else if group.MaxParallel, err = table.getInt("max_parallel"); err != nil {
+		return err
+	} else if group.Directory, err = table.getString("dir"); err != nil {
+		return err
+	} else if group.EnvVars, err = table.getStrings("env"); err != nil {
+		return err
+	} else if group.Arguments, err = table.getStrings("args"); err != nil {
+		return err
+	}
+	if ready, exists := table["ready"]; exists {
+		group.Ready = &ConfigReadyCheck{}
+		if err := group.Ready.UnmarshalTOML(ready); err != nil {
+			return err
+		}
+	}
+	commands, ok := table["commands"].([]interface{})
+	if _, exists := table["commands"]; exists && !ok {
+		return fmt.Errorf("'commands' should be a list")
+	}
+	for _, data := range commands {
+		command := &ConfigExecCommand{}
+		if err := command.UnmarshalTOML(data); err != nil {
+			return err
+		}
+		group.Commands = append(group.Commands, command)
+	}
+	return group.validate()
+}
+
+// validate checks that a group specified as a mapping has commands,
+// that its inputs are valid globs, that only groups which run in the
+// background have a ready check and that its max_parallel isn't negative
+func (group *ConfigExecGroup) validate() error {
+	if len(group.Commands) == 0 {
+		return fmt.Errorf("execution group should specify at least one of 'commands'")
+	} else if group.MaxParallel < 0 {
+		return fmt.Errorf("invalid max_parallel '%v': should not be negative", group.MaxParallel)
+	} else if len(group.Outputs) > 0 && len(group.Inputs) == 0 {
+		return fmt.Errorf("execution group should specify 'inputs' to cache its 'outputs'")
+	} else if group.Ready != nil && !group.Background {
+		return fmt.Errorf("execution group should run in the 'background' to have a 'ready' check")
+	}
+	for _, input := range group.Inputs {
+		if _, err := path.Match(input, ""); err != nil {
+			return fmt.Errorf("invalid input '%s': %s", input, err)
+		}
+	}
+	return nil
+}
+
+// getMaxParallel returns the number of commands the group runs at once,
+// falling back to :defaultMaxParallel when the group doesn't specify it
+func (group *ConfigExecGroup) getMaxParallel(defaultMaxParallel int) int {
+	if group.MaxParallel > 0 {
+		return group.MaxParallel
+	}
+	return defaultMaxParallel
+}
+
+// getReadinessCheck returns the readiness check of the group with a
+// relative file resolved against :workDirectory, or nil if the group
+// doesn't have one
+func (group *ConfigExecGroup) getReadinessCheck(workDirectory string) *ReadinessCheck {
+	if group.Ready == nil {
+		return nil
+	}
+	// the ready check was validated when it was unmarshalled
+	check := &ReadinessCheck{
+		TCP:     group.Ready.TCP,
+		HTTP:    group.Ready.HTTP,
+		Timeout: DefaultReadinessTimeout,
+	}
+	if len(group.Ready.Log) > 0 {
+		check.Log = regexp.MustCompile(group.Ready.Log)
+	}
+	if len(group.Ready.File) > 0 {
+		check.File = resolvePath(workDirectory, group.Ready.File)
+	}
+	if len(group.Ready.Timeout) > 0 {
+		check.Timeout, _ = time.ParseDuration(group.Ready.Timeout)
+	}
+	return check
+}
+
+// UnmarshalYAML validates the ready check of a group
+func (ready *ConfigReadyCheck) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type configReadyCheck ConfigReadyCheck
+	if err := unmarshal((*configReadyCheck)(ready)); err != nil {
+		return err
+	}
+	return ready.validate()
+}
+
+// UnmarshalTOML allows the ready check of a group to be specified as a table
+func (ready *ConfigReadyCheck) UnmarshalTOML(data interface{}) error {
+	table, err := newConfigExecTable(data, "tcp", "http", "log", "file", "timeout")
+	if err != nil {
+		return fmt.Errorf("'ready' %s", err)
+	}
+	if ready.TCP, err = table.getString("tcp"); err != nil {
+		return err
+	} else if ready.HTTP, err = table.getString("http"); err != nil {
+		return err
+	} else if ready.Log, err = table.getString("log"); err != nil {
+		return err
+	} else if ready.File, err = table.getString("file"); err != nil {
+		return err
+	} else if ready.Timeout, err = table.getString("timeout"); err != nil {
+		return err
+	}
+	return ready.validate()
+}
+
+// validate checks that the ready check specifies at least one check
+// and that its values can be parsed
+func (ready *ConfigReadyCheck) validate() error {
+	if len(ready.TCP) == 0 && len(ready.HTTP) == 0 && len(ready.Log) == 0 && len(ready.File) == 0 {
+		return fmt.Errorf("'ready' should specify at least one of 'tcp', 'http', 'log' or 'file'")
+	}
+	if len(ready.TCP) > 0 {
+		if _, _, err := net.SplitHostPort(ready.TCP); err != nil {
+			return fmt.Errorf("invalid 'ready' tcp address '%s': %s", ready.TCP, err)
+		}
+	}
+	if len(ready.HTTP) > 0 && !strings.HasPrefix(ready.HTTP, "http://") && !strings.HasPrefix(ready.HTTP, "https://") {
+		return fmt.Errorf("invalid 'ready' http url '%s': should start with http:// or https://", ready.HTTP)
+	}
+	if len(ready.Log) > 0 {
+		if _, err := regexp.Compile(ready.Log); err != nil {
+			return fmt.Errorf("invalid 'ready' log pattern '%s': %s", ready.Log, err)
+		}
+	}
+	if len(ready.Timeout) > 0 {
+		if _, err := time.ParseDuration(ready.Timeout); err != nil {
+			return fmt.Errorf("invalid 'ready' timeout '%s': %s", ready.Timeout, err)
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML allows a command to be specified as a string or mapping
+func (command *ConfigExecCommand) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&command.Run); err == nil {
+		return nil
+	}
+	type configExecCommand ConfigExecCommand
+	if err := unmarshal((*configExecCommand)(command)); err != nil {
+		return err
+	}
+	return command.validate()
+}
+
+// UnmarshalTOML allows a command to be specified as a string or table
+func (command *ConfigExecCommand) UnmarshalTOML(data interface{}) error {
+	if run, ok := data.(string); ok {
+		command.Run = run
+		return nil
+	}
+	table, err := newConfigExecTable(data, "run", "name", "dir", "env", "args", "continue_on_error", "timeout", "retries")
+	if err != nil {
+		return err
+	}
+	if command.Run, err = table.getString("run"); err != nil {
+		return err
+	} else if command.Name, err = table.getString("name"); err != nil {
+		return err
+	} else if command.Directory, err = table.getString("dir"); err != nil {
+		return err
+	} else if command.EnvVars, err = table.getStrings("env"); err != nil {
+		return err
+	} else if command.Arguments, err = table.getStrings("args"); err != nil {
+		return err
+	} else if command.ContinueOnError, err = table.getBool("continue_on_error"); err != nil {
+		return err
+	} else if command.Timeout, err = table.getString("timeout"); err != nil {
+		return err
+	} else if command.Retries, err = table.getInt("retries"); err != nil {
+		return err
+	}
+	return command.validate()
+}
+
+// validate checks that a command specified as a mapping has a command,
+// a valid timeout and doesn't have negative retries
+func (command *ConfigExecCommand) validate() error {
+	if len(strings.TrimSpace(command.Run)) == 0 {
+		return fmt.Errorf("command should specify 'run'")
+	} else if command.Retries < 0 {
+		return fmt.Errorf("invalid retries '%v' for command '%s': should not be negative", command.Retries, command.Run)
+	}
+	if len(command.Timeout) > 0 {
+		if timeout, err := time.ParseDuration(command.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout '%s' for command '%s': %s", command.Timeout, command.Run, err)
+		} else if timeout <= 0 {
+			return fmt.Errorf("invalid timeout '%s' for command '%s': should be positive", command.Timeout, command.Run)
+		}
+	}
+	return nil
+}
+
+// getTimeout returns the duration after which the command is stopped,
+// or 0 if it doesn't have a timeout
+func (command *ConfigExecCommand) getTimeout() time.Duration {
+	// the timeout was validated when the command was unmarshalled
+	timeout, _ := time.ParseDuration(command.Timeout)
+	return timeout
+}
+
+// UnmarshalYAML allows a file pattern to be specified as a string or mapping
+func (pattern *ChangePattern) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&pattern.Pattern); err == nil {
+		return pattern.validate()
+	}
+	type changePattern ChangePattern
+	if err := unmarshal((*changePattern)(pattern)); err != nil {
+		return err
+	}
+	return pattern.validate()
+}
+
+// UnmarshalTOML allows a file pattern to be specified as a string or table
+func (pattern *ChangePattern) UnmarshalTOML(data interface{}) error {
+	if patternString, ok := data.(string); ok {
+		pattern.Pattern = patternString
+		return pattern.validate()
+	}
+	table, err := newConfigExecTable(data, "pattern", "contains")
+	if err != nil {
+		return err
+	}
+	if pattern.Pattern, err = table.getString("pattern"); err != nil {
+		return err
+	} else if pattern.Contains, err = table.getString("contains"); err != nil {
+		return err
+	}
+	return pattern.validate()
+}
+
+// configExecTable is a TOML table decoded without a struct
+type configExecTable map[string]interface{}
+
+// newConfigExecTable checks that :data is a table with only :keys
+func newConfigExecTable(data interface{}, keys ...string) (configExecTable, error) {
+	table, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a string or table but got '%v'", data)
+	}
+	var unknownKeys []string
+	for key := range table {
+		if !sliceContainsString(keys, key) {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
+		return nil, fmt.Errorf("unknown keys: %s", strings.Join(unknownKeys, ", "))
+	}
+	return configExecTable(table), nil
+}
+
+func (table configExecTable) getString(key string) (string, error) {
+	value, ok := table[key]
+	if !ok {
+		return "", nil
+	} else if stringValue, ok := value.(string); ok {
+		return stringValue, nil
+	}
+	return "", fmt.Errorf("'%s' should be a string", key)
+}
+
+func (table configExecTable) getBool(key string) (bool, error) {
+	value, ok := table[key]
+	if !ok {
+		return false, nil
+	} else if boolValue, ok := value.(bool); ok {
+		return boolValue, nil
+	}
+	return false, fmt.Errorf("'%s' should be a boolean", key)
+}
+
+func (table configExecTable) getInt(key string) (int, error) {
+	value, ok := table[key]
+	if !ok {
+		return 0, nil
+	} else if intValue, ok := value.(int64); ok {
+		return int(intValue), nil
+	}
+	return 0, fmt.Errorf("'%s' should be an integer", key)
+}
+
+func (table configExecTable) getStrings(key string) ([]string, error) {
+	value, ok := table[key]
+	if !ok {
+		return nil, nil
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'%s' should be a list of strings", key)
+	}
+	stringValues := []string{}
+	for _, value := range values {
+		stringValue, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("'%s' should be a list of strings", key)
+		}
+		stringValues = append(stringValues, stringValue)
+	}
+	return stringValues, nil
+}
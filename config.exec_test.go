@@ -0,0 +1,308 @@
+package main
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type ConfigExecTestSuite struct {
+	suite.Suite
+	dataDirectory string
+}
+
+func TestConfigExecTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigExecTestSuite))
+}
+
+func (s *ConfigExecTestSuite) SetupTest() {
+	s.dataDirectory = path.Join(getCurrentWorkingDirectory(), "/data/test-config")
+}
+
+func (s *ConfigExecTestSuite) Test_getPipelineConfigs_withGroupsFromYAML() {
+	t := s.T()
+	workDirectory := path.Join(s.dataDirectory, "/groups")
+	config := &Config{}
+	err := config.applyLayers(&ConfigLayer{WorkDirectory: &workDirectory}, &ConfigLayer{})
+	assert.Nil(t, err)
+	config.assignDefaults()
+	assert.Equal(t, []string{
+		"go mod vendor",
+		"go generate ./..., go build -o ../bin/server ./cmd/server",
+		"bin/server, bin/worker",
+	}, []string(config.ExecGroups))
+	pipeline, err := config.getPipelineConfigs()
+	assert.Nil(t, err)
+	assert.Equal(t, workDirectory, pipeline[0][0].Directory)
+	assert.Equal(t, []string{"APP_ENV=development"}, pipeline[0][0].Environment)
+	assert.Equal(t, path.Join(workDirectory, "/api"), pipeline[1][0].Directory)
+	assert.Equal(t, []string{"APP_ENV=development", "GOFLAGS=-mod=vendor"}, pipeline[1][0].Environment)
+	assert.Equal(t, workDirectory, pipeline[1][1].Directory)
+	assert.Equal(t, []string{"build", "-o", "../bin/server", "./cmd/server"}, pipeline[1][1].Arguments)
+	assert.Equal(t, []string{"APP_ENV=development", "APP_ENV=test", "LOG_LEVEL=test"}, pipeline[2][0].Environment)
+	assert.Equal(t, []string{"--port", "8080"}, pipeline[2][0].Arguments)
+	assert.Equal(t, []string{}, pipeline[2][1].Arguments)
+}
+
+func (s *ConfigExecTestSuite) Test_getPipelineConfigs_withGroupsFromTOML() {
+	t := s.T()
+	file, err := parseConfigFile(path.Join(s.dataDirectory, "/groups-toml/.godev.toml"))
+	assert.Nil(t, err)
+	config := &Config{CommandsDelimiter: ",", WorkDirectory: "/work"}
+	config.applyLayer(&file.ConfigLayer, ConfigSourceFile)
+	pipeline, err := config.getPipelineConfigs()
+	assert.Nil(t, err)
+	assert.Equal(t, "/work/api", pipeline[0][0].Directory)
+	assert.Equal(t, []string{"generate", "./..."}, pipeline[0][0].Arguments)
+	assert.Equal(t, "/work", pipeline[0][1].Directory)
+	assert.Equal(t, []string{"--debug"}, pipeline[1][0].Arguments)
+}
+
+func (s *ConfigExecTestSuite) Test_getExecGroups_fromDelimitedStrings() {
+	t := s.T()
+	config := &Config{ExecGroups: []string{"a,b"}}
+	groups := config.getExecGroups()
+	assert.Equal(t, []string{"a,b"}, groups.Strings())
+	commands := groups[0].getCommands(",")
+	assert.Equal(t, "a", commands[0].Run)
+	assert.Equal(t, "b", commands[1].Run)
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalYAML_withInvalidGroups() {
+	t := s.T()
+	var groups ConfigExecGroups
+	err := yaml.UnmarshalStrict([]byte("- dir: api"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "at least one of 'commands'")
+	err = yaml.UnmarshalStrict([]byte("- commands: [{dir: api}]"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "should specify 'run'")
+	err = yaml.UnmarshalStrict([]byte("- commands: [a]\n  directory: api"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "directory")
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalTOML_withInvalidGroups() {
+	t := s.T()
+	var file struct {
+		Exec ConfigExecGroups `toml:"exec"`
+	}
+	err := decodeTOML([]byte("[[exec]]\ncommands = [\"a\"]\ndirectory = \"api\""), &file)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown keys: directory")
+	err = decodeTOML([]byte("[[exec]]\ncommands = [{ run = \"a\", env = \"A=1\" }]"), &file)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "'env' should be a list of strings")
+}
+
+func (s *ConfigExecTestSuite) Test_getExecGroupDependencies() {
+	t := s.T()
+	config := &Config{ExecGroupConfigs: ConfigExecGroups{
+		{Name: "generate", Commands: []*ConfigExecCommand{{Run: "go generate ./..."}}},
+		{Name: "vet", DependsOn: []string{"generate"}, Commands: []*ConfigExecCommand{{Run: "go vet ./..."}}},
+		{Name: "build", DependsOn: []string{"generate"}, Commands: []*ConfigExecCommand{{Run: "go build"}}},
+		{DependsOn: []string{"vet", "build"}, Commands: []*ConfigExecCommand{{Run: "bin/app"}}},
+		{Commands: []*ConfigExecCommand{{Run: "echo done"}}},
+		{DependsOn: []string{}, Commands: []*ConfigExecCommand{{Run: "echo independent"}}},
+	}}
+	names, dependencies, err := config.getExecGroupDependencies()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"generate", "vet", "build", "4", "5", "6"}, names)
+	assert.Equal(t, [][]int{nil, {0}, {0}, {1, 2}, {3}, {}}, dependencies)
+}
+
+func (s *ConfigExecTestSuite) Test_getExecGroupDependencies_withProblems() {
+	t := s.T()
+	config := &Config{ExecGroupConfigs: ConfigExecGroups{
+		{Name: "a", DependsOn: []string{"c"}, Commands: []*ConfigExecCommand{{Run: "echo a"}}},
+		{Name: "b", DependsOn: []string{"a", "unknown"}, Commands: []*ConfigExecCommand{{Run: "echo b"}}},
+		{Name: "c", Commands: []*ConfigExecCommand{{Run: "echo c"}}},
+		{Name: "a", DependsOn: []string{}, Commands: []*ConfigExecCommand{{Run: "echo a"}}},
+	}}
+	_, _, err := config.getExecGroupDependencies()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "execution group 4 has the same name as another group ('a')")
+	assert.Contains(t, err.Error(), "execution group 'b' depends on 'unknown' which does not exist")
+	assert.Contains(t, err.Error(), "cyclic dependency: a > c > b > a")
+	_, err = config.getPipelineConfigs()
+	assert.Contains(t, err.Error(), "cyclic dependency")
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalYAML_withWhenChanged() {
+	t := s.T()
+	var groups ConfigExecGroups
+	err := yaml.UnmarshalStrict([]byte(`
+- when_changed: [go.mod, {pattern: "*.go", contains: "//go:generate"}]
+  commands: [go generate ./...]
+`), &groups)
+	assert.Nil(t, err)
+	assert.Equal(t, []*ChangePattern{{Pattern: "go.mod"}, {Pattern: "*.go", Contains: "//go:generate"}}, groups[0].WhenChanged)
+	err = yaml.UnmarshalStrict([]byte("- {when_changed: ['[a'], commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid file pattern '[a'")
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalTOML_withWhenChanged() {
+	t := s.T()
+	var file struct {
+		Exec ConfigExecGroups `toml:"exec"`
+	}
+	err := decodeTOML([]byte("[[exec]]\nwhen_changed = [\"*.sql\"]\ncommands = [{ run = \"make migrate\" }]"), &file)
+	assert.Nil(t, err)
+	assert.Equal(t, []*ChangePattern{{Pattern: "*.sql"}}, file.Exec[0].WhenChanged)
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalYAML_withInputsAndOutputs() {
+	t := s.T()
+	var groups ConfigExecGroups
+	err := yaml.UnmarshalStrict([]byte(`
+- inputs: ["api/**/*.proto"]
+  outputs: [api/generated]
+  commands: [go generate ./api/...]
+`), &groups)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api/**/*.proto"}, groups[0].Inputs)
+	assert.Equal(t, []string{"api/generated"}, groups[0].Outputs)
+	err = yaml.UnmarshalStrict([]byte("- {outputs: [bin], commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "'inputs'")
+	err = yaml.UnmarshalStrict([]byte("- {inputs: ['[a'], commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid input '[a'")
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalTOML_withInputsAndOutputs() {
+	t := s.T()
+	var file struct {
+		Exec ConfigExecGroups `toml:"exec"`
+	}
+	err := decodeTOML([]byte("[[exec]]\ninputs = [\"go.mod\", \"go.sum\"]\noutputs = [\"vendor\"]\ncommands = [{ run = \"go mod vendor\" }]"), &file)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"go.mod", "go.sum"}, file.Exec[0].Inputs)
+	assert.Equal(t, []string{"vendor"}, file.Exec[0].Outputs)
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalYAML_withReadyCheck() {
+	t := s.T()
+	var groups ConfigExecGroups
+	err := yaml.UnmarshalStrict([]byte(`
+- name: stub
+  background: true
+  ready: {tcp: "localhost:8081", log: "listening on", timeout: 10s}
+  commands: [go run ./stub]
+`), &groups)
+	assert.Nil(t, err)
+	assert.True(t, groups[0].Background)
+	assert.Equal(t, &ConfigReadyCheck{TCP: "localhost:8081", Log: "listening on", Timeout: "10s"}, groups[0].Ready)
+	check := groups[0].getReadinessCheck("/work")
+	assert.Equal(t, "tcp localhost:8081, log listening on", check.String())
+	assert.Equal(t, 10*time.Second, check.Timeout)
+	err = yaml.UnmarshalStrict([]byte("- {ready: {file: ready}, commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "should run in the 'background' to have a 'ready' check")
+	err = yaml.UnmarshalStrict([]byte("- {background: true, ready: {timeout: 1s}, commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "'ready' should specify at least one of")
+	err = yaml.UnmarshalStrict([]byte("- {background: true, ready: {log: '[a'}, commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid 'ready' log pattern '[a'")
+	err = yaml.UnmarshalStrict([]byte("- {background: true, ready: {http: 'localhost:8080'}, commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid 'ready' http url")
+	err = yaml.UnmarshalStrict([]byte("- {background: true, ready: {tcp: '8080'}, commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid 'ready' tcp address")
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalTOML_withReadyCheck() {
+	t := s.T()
+	var file struct {
+		Exec ConfigExecGroups `toml:"exec"`
+	}
+	err := decodeTOML([]byte("[[exec]]\nbackground = true\nready = { http = \"http://localhost:8080/healthz\", file = \"tmp/ready\" }\ncommands = [\"bin/mock-auth\"]"), &file)
+	assert.Nil(t, err)
+	assert.True(t, file.Exec[0].Background)
+	check := file.Exec[0].getReadinessCheck("/work")
+	assert.Equal(t, "http://localhost:8080/healthz", check.HTTP)
+	assert.Equal(t, "/work/tmp/ready", check.File)
+	assert.Equal(t, DefaultReadinessTimeout, check.Timeout)
+	err = decodeTOML([]byte("[[exec]]\nbackground = true\nready = { port = 8080 }\ncommands = [\"a\"]"), &file)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "'ready' unknown keys: port")
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalYAML_withMaxParallel() {
+	t := s.T()
+	var groups ConfigExecGroups
+	err := yaml.UnmarshalStrict([]byte("- {max_parallel: 2, commands: [go test ./a/..., go test ./b/..., go test ./c/...]}\n- {commands: [bin/app]}"), &groups)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, groups[0].getMaxParallel(4))
+	assert.Equal(t, 4, groups[1].getMaxParallel(4))
+	err = yaml.UnmarshalStrict([]byte("- {max_parallel: -1, commands: [a]}"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid max_parallel '-1': should not be negative")
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalTOML_withMaxParallel() {
+	t := s.T()
+	var file struct {
+		Exec ConfigExecGroups `toml:"exec"`
+	}
+	err := decodeTOML([]byte("[[exec]]\nmax_parallel = 1\ncommands = [\"go test ./a/...\", \"go test ./b/...\"]"), &file)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, file.Exec[0].MaxParallel)
+	err = decodeTOML([]byte("[[exec]]\nmax_parallel = \"one\"\ncommands = [\"a\"]"), &file)
+	assert.NotNil(t, err)
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalYAML_withCommandName() {
+	t := s.T()
+	var groups ConfigExecGroups
+	err := yaml.UnmarshalStrict([]byte("- commands: [{run: bin/app api, name: api}, bin/app worker]"), &groups)
+	assert.Nil(t, err)
+	assert.Equal(t, "api", groups[0].Commands[0].Name)
+	assert.Empty(t, groups[0].Commands[1].Name)
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalTOML_withCommandName() {
+	t := s.T()
+	var file struct {
+		Exec ConfigExecGroups `toml:"exec"`
+	}
+	err := decodeTOML([]byte("[[exec]]\ncommands = [{ run = \"bin/app api\", name = \"api\" }]"), &file)
+	assert.Nil(t, err)
+	assert.Equal(t, "api", file.Exec[0].Commands[0].Name)
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalYAML_withTimeoutAndRetries() {
+	t := s.T()
+	var groups ConfigExecGroups
+	err := yaml.UnmarshalStrict([]byte("- commands: [{run: go mod download, timeout: 2m, retries: 3}]"), &groups)
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Minute, groups[0].Commands[0].getTimeout())
+	assert.Equal(t, 3, groups[0].Commands[0].Retries)
+	err = yaml.UnmarshalStrict([]byte("- commands: [{run: a, timeout: soon}]"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid timeout 'soon' for command 'a'")
+	err = yaml.UnmarshalStrict([]byte("- commands: [{run: a, retries: -1}]"), &groups)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid retries '-1' for command 'a'")
+}
+
+func (s *ConfigExecTestSuite) TestUnmarshalTOML_withTimeoutAndRetries() {
+	t := s.T()
+	var file struct {
+		Exec ConfigExecGroups `toml:"exec"`
+	}
+	err := decodeTOML([]byte("[[exec]]\ncommands = [{ run = \"go test ./...\", timeout = \"5m\", retries = 1 }]"), &file)
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, file.Exec[0].Commands[0].getTimeout())
+	assert.Equal(t, 1, file.Exec[0].Commands[0].Retries)
+	err = decodeTOML([]byte("[[exec]]\ncommands = [{ run = \"a\", retries = \"1\" }]"), &file)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "'retries' should be an integer")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigFileNames are the file names godev looks for in the work
+// directory, in order of preference
+var ConfigFileNames = []string{".godev.yml", ".godev.yaml", ".godev.toml"}
+
+// ConfigFile represents the contents of the config file, the
+// top-level keys apply to every run while profiles are only
+// applied when selected with --profile
+type ConfigFile struct {
+	ConfigLayer `yaml:",inline"`
+	Profiles    map[string]*ConfigProfile `yaml:"profiles" toml:"profiles"`
+}
+
+// ConfigProfile is a named set of configurations which can inherit
+// from another profile through :Extends
+type ConfigProfile struct {
+	ConfigLayer `yaml:",inline"`
+	Extends     string `yaml:"extends" toml:"extends"`
+}
+
+// GetProfile resolves the profile :name by merging it over the
+// profiles it extends
+func (file *ConfigFile) GetProfile(name string) (*ConfigLayer, error) {
+	var lineage []string
+	layer := &ConfigLayer{}
+	for current := name; len(current) > 0; {
+		if sliceContainsString(lineage, current) {
+			return nil, fmt.Errorf("profile '%s' has a cyclic inheritance: %s > %s", name, strings.Join(lineage, " > "), current)
+		}
+		profile, ok := file.Profiles[current]
+		if !ok {
+			if len(lineage) > 0 {
+				return nil, fmt.Errorf("profile '%s' extends '%s' which does not exist", lineage[len(lineage)-1], current)
+			}
+			return nil, fmt.Errorf("profile '%s' does not exist (available: %s)", current, strings.Join(file.GetProfileNames(), ", "))
+		}
+		lineage = append(lineage, current)
+		current = profile.Extends
+	}
+	for index := len(lineage) - 1; index >= 0; index-- {
+		layer.merge(&file.Profiles[lineage[index]].ConfigLayer)
+	}
+	return layer, nil
+}
+
+// GetProfileNames returns the names of all profiles in alphabetical order
+func (file *ConfigFile) GetProfileNames() []string {
+	names := []string{}
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findConfigFile returns the path to the first config file found
+// in :directory, or an empty string if there isn't one
+func findConfigFile(directory string) string {
+	for _, fileName := range ConfigFileNames {
+		pathToFile := path.Join(directory, fileName)
+		if fileExists(pathToFile) {
+			return pathToFile
+		}
+	}
+	return ""
+}
+
+// loadConfigFile discovers and parses the config file in :directory,
+// returning an empty config file if none was found
+func loadConfigFile(directory string) (*ConfigFile, string, error) {
+	pathToFile := findConfigFile(directory)
+	if len(pathToFile) == 0 {
+		return &ConfigFile{}, "", nil
+	}
+	file, err := parseConfigFile(pathToFile)
+	if err != nil {
+		return nil, pathToFile, err
+	}
+	return file, pathToFile, nil
+}
+
+// parseConfigFile parses the YAML/TOML file at :pathToFile with
+// relative directories resolved against the file's directory
+func parseConfigFile(pathToFile string) (*ConfigFile, error) {
+	data, err := ioutil.ReadFile(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	file := &ConfigFile{}
+	switch strings.ToLower(path.Ext(pathToFile)) {
+	case ".yml", ".yaml":
+		err = yaml.UnmarshalStrict(data, file)
+	case ".toml":
+		err = decodeTOML(data, file)
+	default:
+		err = fmt.Errorf("unsupported config file format '%s'", path.Ext(pathToFile))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse '%s': %s", pathToFile, err)
+	}
+	baseDirectory := path.Dir(pathToFile)
+	file.ConfigLayer.resolvePaths(baseDirectory)
+	for name, profile := range file.Profiles {
+		if profile == nil {
+			return nil, fmt.Errorf("profile '%s' in '%s' is empty", name, pathToFile)
+		}
+		profile.ConfigLayer.resolvePaths(baseDirectory)
+	}
+	return file, nil
+}
+
+// decodeTOML decodes :data into :into and errors on unknown keys so
+// that typos don't go unnoticed. Keys within execution groups are
+// checked by ConfigExecGroup instead
+func decodeTOML(data []byte, into interface{}) error {
+	metadata, err := toml.Decode(string(data), into)
+	if err != nil {
+		return err
+	}
+	keys := []string{}
+	for _, key := range metadata.Undecoded() {
+		if isWithinExecGroup(key) {
+			continue
+		}
+		keys = append(keys, key.String())
+	}
+	if len(keys) > 0 {
+		return fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// isWithinExecGroup checks whether :key is nested within an 'exec' key
+func isWithinExecGroup(key toml.Key) bool {
+	for index := 0; index < len(key)-1; index++ {
+		if key[index] == "exec" {
+			return true
+		}
+	}
+	return false
+}
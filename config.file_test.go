@@ -0,0 +1,97 @@
+package main
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigFileTestSuite struct {
+	suite.Suite
+	dataDirectory string
+}
+
+func TestConfigFileTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigFileTestSuite))
+}
+
+func (s *ConfigFileTestSuite) SetupTest() {
+	s.dataDirectory = path.Join(getCurrentWorkingDirectory(), "/data/test-config")
+}
+
+func (s *ConfigFileTestSuite) Test_findConfigFile() {
+	assert.Equal(s.T(), path.Join(s.dataDirectory, "/yml/.godev.yml"), findConfigFile(path.Join(s.dataDirectory, "/yml")))
+	assert.Equal(s.T(), path.Join(s.dataDirectory, "/toml/.godev.toml"), findConfigFile(path.Join(s.dataDirectory, "/toml")))
+	assert.Equal(s.T(), "", findConfigFile(s.dataDirectory))
+}
+
+func (s *ConfigFileTestSuite) Test_loadConfigFile_withoutFile() {
+	file, pathToFile, err := loadConfigFile(s.dataDirectory)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "", pathToFile)
+	assert.Equal(s.T(), &ConfigFile{}, file)
+}
+
+func (s *ConfigFileTestSuite) Test_loadConfigFile_yml() {
+	t := s.T()
+	layer, pathToFile, err := loadConfigFile(path.Join(s.dataDirectory, "/yml"))
+	assert.Nil(t, err)
+	assert.Equal(t, path.Join(s.dataDirectory, "/yml/.godev.yml"), pathToFile)
+	assert.Equal(t, "bin/server", *layer.BuildOutput)
+	assert.Equal(t, []string{"--port", "8080"}, layer.CommandArguments)
+	assert.Equal(t, []string{"APP_ENV=development"}, layer.EnvVars)
+	assert.Equal(t, []string{"go mod vendor", "go build -o bin/server", "bin/server"}, layer.ExecGroups.Strings())
+	assert.Equal(t, []string{"go", "yml"}, layer.FileExtensions)
+	assert.Equal(t, []string{"bin", "vendor", "node_modules"}, layer.IgnoredNames)
+	assert.Equal(t, "1s", *layer.Rate)
+	assert.Equal(t, path.Join(s.dataDirectory, "/yml/src"), *layer.WatchDirectory)
+	assert.Nil(t, layer.CommandsDelimiter)
+	assert.Nil(t, layer.WorkDirectory)
+}
+
+func (s *ConfigFileTestSuite) Test_loadConfigFile_toml() {
+	t := s.T()
+	layer, _, err := loadConfigFile(path.Join(s.dataDirectory, "/toml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "bin/server", *layer.BuildOutput)
+	assert.Equal(t, ";", *layer.CommandsDelimiter)
+	assert.Equal(t, []string{"go build -o bin/server", "bin/server"}, layer.ExecGroups.Strings())
+	assert.Equal(t, []string{"go", "toml"}, layer.FileExtensions)
+	assert.Equal(t, "3s", *layer.Rate)
+	assert.Nil(t, layer.WatchDirectory)
+}
+
+func (s *ConfigFileTestSuite) Test_loadConfigFile_withUnknownKeys() {
+	_, _, err := loadConfigFile(path.Join(s.dataDirectory, "/invalid"))
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "execs")
+}
+
+func (s *ConfigFileTestSuite) TestGetProfile_withInheritance() {
+	t := s.T()
+	file, _, err := loadConfigFile(path.Join(s.dataDirectory, "/profiles"))
+	assert.Nil(t, err)
+	profile, err := file.GetProfile("integration")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"go build -o bin/app", "bin/app worker"}, profile.ExecGroups.Strings())
+	assert.Equal(t, []string{"PORT=8080"}, profile.EnvVars)
+	assert.Equal(t, []string{"go", "sql"}, profile.FileExtensions)
+}
+
+func (s *ConfigFileTestSuite) TestGetProfile_withCycle() {
+	file, _, err := loadConfigFile(path.Join(s.dataDirectory, "/profiles"))
+	assert.Nil(s.T(), err)
+	_, err = file.GetProfile("looped")
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "cyclic")
+}
+
+func (s *ConfigFileTestSuite) TestGetProfile_nonExistent() {
+	file, _, err := loadConfigFile(path.Join(s.dataDirectory, "/profiles"))
+	assert.Nil(s.T(), err)
+	_, err = file.GetProfile("nope")
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "api, cyclic, integration, looped, worker")
+}
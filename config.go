@@ -5,6 +5,8 @@ import (
 	"path"
 	"strings"
 	"time"
+
+	shellquote "github.com/kballard/go-shellquote"
 )
 
 // DefaultBuildOutput - default relative path to watch directory to place built binaries in
@@ -16,6 +18,15 @@ const DefaultCommandArguments = ""
 // DefaultCommandsDelimiter - default string to split --execs into commands with
 const DefaultCommandsDelimiter = ","
 
+// DefaultCrashLoopExits - default number of exits within the --crash-loop-window after which the final execution group stops being restarted
+const DefaultCrashLoopExits = 5
+
+// DefaultCrashLoopWindow - default duration in which --crash-loop-exits exits of the final execution group make it a crash loop
+const DefaultCrashLoopWindow = time.Minute
+
+// DefaultEnvInherit - default environment variables from godev's environment to pass to commands
+const DefaultEnvInherit = EnvironmentInheritAll
+
 // DefaultExecutionGroupsBase - default commands to run when no --execs are specified
 var DefaultExecutionGroupsBase = []string{"go mod vendor"}
 
@@ -28,28 +39,79 @@ const DefaultIgnoredNames = "bin,vendor"
 // DefaultLogLevel - default log level from 'trace', 'debug', 'info', 'warn', 'error', 'panic'
 const DefaultLogLevel = "info"
 
+// DefaultLogsKeepRuns - default number of pipeline runs whose command output is kept in log files, 0 disables the log files
+const DefaultLogsKeepRuns = 20
+
+// DefaultLogsMaxSize - default size the log files of pipeline runs are kept under, 0 for no limit
+const DefaultLogsMaxSize = "100MB"
+
+// DefaultMaxParallel - default number of commands an execution group runs at once, 0 runs all of them at once
+const DefaultMaxParallel = 0
+
+// DefaultOutputMode - default way the output of commands is written from 'raw', 'prefixed' or 'grouped'
+const DefaultOutputMode = OutputModeRaw
+
 // DefaultRefreshRate - default duration at which to handle file system events
 const DefaultRefreshRate = 2 * time.Second
 
+// DefaultRestartPolicy - default policy for restarting the final execution group when it exits on its own
+const DefaultRestartPolicy = RestartPolicyNever
+
+// DefaultShell - default shell to run commands through, commands are run directly when empty
+const DefaultShell = ""
+
+// DefaultStopSignals - default signals sent to the process groups of commands being stopped and when they're sent
+const DefaultStopSignals = "SIGINT,SIGTERM:5s,SIGKILL:10s"
+
+// StagedBuildOutputSuffix - suffix of the path execution groups before the final one build to with --swap
+const StagedBuildOutputSuffix = ".next"
+
+// ShellCommandPrefix - prefix marking a single command to be run through a shell
+const ShellCommandPrefix = "sh:"
+
+// ShellCommandPrefixShell - shell used for commands with the ShellCommandPrefix when --shell is not set
+const ShellCommandPrefixShell = "/bin/sh"
+
 // Config configures the main application entrypoint
 type Config struct {
 	BuildOutput       string
 	CommandArguments  ConfigCommaDelimitedString
 	CommandsDelimiter string
+	ConfigFile        string
+	CrashLoopExits    int
+	CrashLoopWindow   time.Duration
+	EnvFiles          ConfigMultiflagString
+	EnvInherit        string
 	EnvVars           ConfigMultiflagString
 	ExecGroups        ConfigMultiflagString
+	ExecGroupConfigs  ConfigExecGroups
 	FileExtensions    ConfigCommaDelimitedString
 	IgnoredNames      ConfigCommaDelimitedString
 	LogLevel          LogLevel
 	LogSilent         bool
 	LogSuperVerbose   bool
 	LogVerbose        bool
+	LogsKeepRuns      int
+	LogsMaxSize       string
+	MaxParallel       int
+	OutputMode        string
+	Profile           string
+	PTY               bool
 	Rate              time.Duration
 	RunDefault        bool
 	RunInit           bool
+	RunConfig         bool
+	RunLogs           bool
 	RunTest           bool
 	RunVersion        bool
 	RunView           bool
+	Stdin             bool
+	RestartAlways     bool
+	RestartPolicy     string
+	Shell             string
+	Sources           map[string]string
+	StopSignals       string
+	Swap              bool
 	View              string
 	WatchDirectory    string
 	WorkDirectory     string
@@ -62,21 +124,90 @@ func (config *Config) interpretLogLevel() {
 	if config.LogSuperVerbose {
 		config.LogLevel = "trace"
 	}
-	if config.LogSilent || config.RunVersion || config.RunView {
+	if config.LogSilent || config.RunVersion || config.RunView || config.RunConfig || config.RunLogs {
 		config.LogLevel = "panic"
 	}
 }
 
 func (config *Config) assignDefaults() {
 	config.LogLevel = DefaultLogLevel
+	if len(config.WorkDirectory) == 0 {
+		config.WorkDirectory = getCurrentWorkingDirectory()
+	}
+	config.setDefaultSource("dir")
+	if len(config.WatchDirectory) == 0 {
+		config.WatchDirectory = getCurrentWorkingDirectory()
+	}
+	config.setDefaultSource("watch")
+	if len(config.BuildOutput) == 0 {
+		config.BuildOutput = DefaultBuildOutput
+	}
+	config.setDefaultSource("output")
 	config.BuildOutput = path.Join(config.WorkDirectory, "/"+config.BuildOutput)
+	if len(config.CommandsDelimiter) == 0 {
+		config.CommandsDelimiter = DefaultCommandsDelimiter
+	}
+	config.setDefaultSource("exec-delim")
+	if config.Rate == 0 {
+		config.Rate = DefaultRefreshRate
+	}
+	config.setDefaultSource("rate")
 	config.RunView = len(config.View) > 0
 	if len(config.IgnoredNames) == 0 {
 		config.IgnoredNames = strings.Split(DefaultIgnoredNames, ",")
 	}
+	config.setDefaultSource("ignore")
 	if len(config.FileExtensions) == 0 {
 		config.FileExtensions = strings.Split(DefaultFileExtensions, ",")
 	}
+	config.setDefaultSource("exts")
+	for index, envFile := range config.EnvFiles {
+		config.EnvFiles[index] = resolvePath(config.WorkDirectory, envFile)
+	}
+	config.setDefaultSource("env-file")
+	if len(config.EnvInherit) == 0 {
+		config.EnvInherit = DefaultEnvInherit
+	}
+	config.setDefaultSource("env-inherit")
+	if config.EnvVars == nil {
+		config.EnvVars = []string{}
+	}
+	config.setDefaultSource("env")
+	config.setDefaultSource("shell")
+	config.setDefaultSource("swap")
+	config.setDefaultSource("pty")
+	config.setDefaultSource("stdin")
+	config.setDefaultSource("restart-always")
+	if len(config.RestartPolicy) == 0 {
+		config.RestartPolicy = DefaultRestartPolicy
+	}
+	config.setDefaultSource("restart-policy")
+	if config.CrashLoopExits == 0 {
+		config.CrashLoopExits = DefaultCrashLoopExits
+	}
+	config.setDefaultSource("crash-loop-exits")
+	if config.CrashLoopWindow == 0 {
+		config.CrashLoopWindow = DefaultCrashLoopWindow
+	}
+	config.setDefaultSource("crash-loop-window")
+	if _, ok := config.Sources["logs-keep-runs"]; !ok {
+		config.LogsKeepRuns = DefaultLogsKeepRuns
+	}
+	config.setDefaultSource("logs-keep-runs")
+	if len(config.LogsMaxSize) == 0 {
+		config.LogsMaxSize = DefaultLogsMaxSize
+	}
+	config.setDefaultSource("logs-max-size")
+	config.setDefaultSource("max-parallel")
+	if len(config.OutputMode) == 0 {
+		config.OutputMode = DefaultOutputMode
+	}
+	config.setDefaultSource("output-mode")
+	if len(config.StopSignals) == 0 {
+		config.StopSignals = DefaultStopSignals
+	}
+	config.setDefaultSource("stop-signals")
+	config.setDefaultSource("args")
 	if len(config.ExecGroups) == 0 {
 		if config.RunTest {
 			testFlags := "-coverprofile c.out"
@@ -85,15 +216,244 @@ func (config *Config) assignDefaults() {
 			}
 			config.ExecGroups = append(
 				DefaultExecutionGroupsBase,
-				fmt.Sprintf("go build -o %s", config.BuildOutput),
+				fmt.Sprintf("go build -o %s", config.getStagedBuildOutput()),
 				fmt.Sprintf("go test ./... %s", testFlags),
 			)
 		} else {
 			config.ExecGroups = append(
 				DefaultExecutionGroupsBase,
-				fmt.Sprintf("go build -o %s", config.BuildOutput),
+				fmt.Sprintf("go build -o %s", config.getStagedBuildOutput()),
 				config.BuildOutput,
 			)
 		}
 	}
+	config.setDefaultSource("exec")
+}
+
+// getStagedBuildOutput returns the path which execution groups before the
+// final one build to, this is next to the build output when swapping so
+// that the running application is only replaced after a successful build
+func (config *Config) getStagedBuildOutput() string {
+	if config.Swap {
+		return config.BuildOutput + StagedBuildOutputSuffix
+	}
+	return config.BuildOutput
+}
+
+// getLongRunningOutputMode returns the output mode of the commands in the
+// final and background execution groups, these keep running so their
+// output is prefixed instead of being held back until they exit
+func (config *Config) getLongRunningOutputMode() string {
+	if config.OutputMode == OutputModeGrouped {
+		return OutputModePrefixed
+	}
+	return config.OutputMode
+}
+
+// getFinalOutputMode returns the output mode of the commands in the final
+// execution group, which is raw when stdin is forwarded to it so that
+// prompts which don't end with a newline are shown as they are written
+func (config *Config) getFinalOutputMode() string {
+	if config.Stdin {
+		return OutputModeRaw
+	}
+	return config.getLongRunningOutputMode()
+}
+
+// getRunLogs returns where the output of commands in each pipeline run
+// of the session starting now is logged to, or nil when the log files
+// are disabled
+func (config *Config) getRunLogs() *RunLogs {
+	if config.LogsKeepRuns <= 0 {
+		return nil
+	}
+	maxSize, _ := parseByteSize(config.LogsMaxSize)
+	return &RunLogs{
+		Directory: path.Join(config.WorkDirectory, DefaultLogsDirectory),
+		Session:   time.Now().Format(RunLogsSessionFormat),
+		MaxRuns:   config.LogsKeepRuns,
+		MaxSize:   maxSize,
+	}
+}
+
+// getPipelineConfigs parses the execution groups into configurations
+// for the commands they contain. Commands run in the directory and with
+// the environment and arguments of their group, falling back to the
+// global ones where CommandArguments only apply to the final execution
+// group. Commands are interpolated after they're split into arguments,
+// those which run through a shell are passed to it as-is for the shell
+// to expand once the variables they reference are known to be set.
+// Commands which could not be parsed are left out
+// of the returned pipeline and reported in the returned ConfigProblems
+func (config *Config) getPipelineConfigs() ([][]*CommandConfig, error) {
+	var pipeline [][]*CommandConfig
+	var problems ConfigProblems
+	environment, err := config.getCommandEnvironment()
+	if err != nil {
+		problems = append(problems, err)
+	}
+	if _, err := parseEnvironmentInherit(config.EnvInherit); err != nil {
+		problems = append(problems, err)
+	}
+	var stopSignals StopSignals
+	if len(config.StopSignals) > 0 {
+		if stopSignals, err = parseStopSignals(config.StopSignals); err != nil {
+			problems = append(problems, fmt.Errorf("invalid --stop-signals '%s': %s", config.StopSignals, err))
+		}
+	}
+	if len(config.RestartPolicy) > 0 {
+		if err := validateRestartPolicy(config.RestartPolicy); err != nil {
+			problems = append(problems, fmt.Errorf("invalid --restart-policy '%s': %s", config.RestartPolicy, err))
+		}
+	}
+	if config.CrashLoopExits < 0 {
+		problems = append(problems, fmt.Errorf("invalid --crash-loop-exits '%v': should not be negative", config.CrashLoopExits))
+	}
+	if len(config.OutputMode) > 0 {
+		if err := validateOutputMode(config.OutputMode); err != nil {
+			problems = append(problems, fmt.Errorf("invalid --output-mode '%s': %s", config.OutputMode, err))
+		}
+	}
+	if config.LogsKeepRuns < 0 {
+		problems = append(problems, fmt.Errorf("invalid --logs-keep-runs '%v': should not be negative", config.LogsKeepRuns))
+	}
+	if len(config.LogsMaxSize) > 0 {
+		if _, err := parseByteSize(config.LogsMaxSize); err != nil {
+			problems = append(problems, fmt.Errorf("invalid --logs-max-size '%s': %s", config.LogsMaxSize, err))
+		}
+	}
+	if config.MaxParallel < 0 {
+		problems = append(problems, fmt.Errorf("invalid --max-parallel '%v': should not be negative", config.MaxParallel))
+	}
+	if _, _, err := config.getExecGroupDependencies(); err != nil {
+		problems = append(problems, err.(ConfigProblems)...)
+	}
+	execGroups := config.getExecGroups()
+	for execGroupIndex, execGroup := range execGroups {
+		var group []*CommandConfig
+		isLastGroup := execGroupIndex == len(execGroups)-1
+		godevEnvironment := config.getGodevEnvironment(isLastGroup)
+		for commandIndex, execCommand := range execGroup.getCommands(config.CommandsDelimiter) {
+			command := execCommand.Run
+			directory, commandEnvironment, arguments := config.getCommandOptions(execGroup, execCommand, isLastGroup, environment)
+			interpolationEnvironment := config.getInterpolationEnvironment(godevEnvironment, commandEnvironment)
+			var commandConfig *CommandConfig
+			if shell, script, ok := config.getShellCommand(command); ok {
+				if len(strings.TrimSpace(script)) == 0 {
+					problems = append(problems, fmt.Errorf("execution group %v, command %v is empty", execGroupIndex+1, commandIndex+1))
+					continue
+				} else if unknown := getUnknownShellReferences(script, interpolationEnvironment); len(unknown) > 0 {
+					problems = append(problems, fmt.Errorf("execution group %v, command %v ('%s') references unknown variable(s) %s", execGroupIndex+1, commandIndex+1, command, strings.Join(unknown, ", ")))
+					continue
+				}
+				if len(arguments) > 0 {
+					script = script + " " + shellquote.Join(arguments...)
+				}
+				commandConfig = config.getCommandConfig(shell, []string{"-c", script}, directory, godevEnvironment, commandEnvironment)
+			} else {
+				sections, err := shellquote.Split(command)
+				if err != nil {
+					problems = append(problems, fmt.Errorf("execution group %v, command %v ('%s') could not be parsed: %s", execGroupIndex+1, commandIndex+1, command, err))
+					continue
+				}
+				if sections, err = config.interpolate(sections, interpolationEnvironment); err != nil {
+					problems = append(problems, fmt.Errorf("execution group %v, command %v ('%s') could not be interpolated: %s", execGroupIndex+1, commandIndex+1, command, err))
+					continue
+				} else if len(sections) == 0 || len(sections[0]) == 0 {
+					problems = append(problems, fmt.Errorf("execution group %v, command %v is empty", execGroupIndex+1, commandIndex+1))
+					continue
+				}
+				commandConfig = config.getCommandConfig(sections[0], append(sections[1:], arguments...), directory, godevEnvironment, commandEnvironment)
+			}
+			commandConfig.ContinueOnError = execCommand.ContinueOnError
+			commandConfig.Name = execCommand.Name
+			commandConfig.Retries = execCommand.Retries
+			commandConfig.StopSignals = stopSignals
+			commandConfig.Timeout = execCommand.getTimeout()
+			group = append(group, commandConfig)
+		}
+		pipeline = append(pipeline, group)
+	}
+	if len(problems) > 0 {
+		return pipeline, problems
+	}
+	return pipeline, nil
+}
+
+// getCommandOptions returns the directory, environment and arguments
+// which :command runs with, where options of the command take precedence
+// over those of its :group which take precedence over the global ones
+func (config *Config) getCommandOptions(group *ConfigExecGroup, command *ConfigExecCommand, isLastGroup bool, environment []string) (string, []string, []string) {
+	directory := config.WorkDirectory
+	commandEnvironment := append([]string{}, environment...)
+	var arguments []string
+	if isLastGroup {
+		arguments = config.CommandArguments
+	}
+	groupOptions := &ConfigExecCommand{Directory: group.Directory, EnvVars: group.EnvVars, Arguments: group.Arguments}
+	for _, options := range []*ConfigExecCommand{groupOptions, command} {
+		if len(options.Directory) > 0 {
+			directory = resolvePath(config.WorkDirectory, options.Directory)
+		}
+		for _, envVar := range options.EnvVars {
+			commandEnvironment = append(commandEnvironment, expandEnvironmentVariable(envVar, commandEnvironment))
+		}
+		if options.Arguments != nil {
+			arguments = options.Arguments
+		}
+	}
+	return directory, commandEnvironment, arguments
+}
+
+// getShellCommand checks whether :command should run through a shell,
+// returning the shell and the script to pass to it
+func (config *Config) getShellCommand(command string) (string, string, bool) {
+	trimmedCommand := strings.TrimSpace(command)
+	if strings.HasPrefix(trimmedCommand, ShellCommandPrefix) {
+		shell := config.Shell
+		if len(shell) == 0 {
+			shell = ShellCommandPrefixShell
+		}
+		return shell, strings.TrimSpace(strings.TrimPrefix(trimmedCommand, ShellCommandPrefix)), true
+	} else if len(config.Shell) > 0 {
+		return config.Shell, trimmedCommand, true
+	}
+	return "", "", false
+}
+
+// getCommandConfig creates the configuration for running :application
+func (config *Config) getCommandConfig(application string, arguments []string, directory string, godevEnvironment []string, environment []string) *CommandConfig {
+	return &CommandConfig{
+		Application:        application,
+		Arguments:          arguments,
+		Directory:          directory,
+		Environment:        environment,
+		EnvironmentInherit: config.EnvInherit,
+		GodevEnvironment:   godevEnvironment,
+		LogLevel:           config.LogLevel,
+		PTY:                config.PTY,
+	}
+}
+
+// getCommandEnvironment returns the variables godev provides to commands,
+// values from env files come first so that --env values take precedence.
+// References in --env values (eg. PATH=$PATH:./bin) are expanded
+func (config *Config) getCommandEnvironment() ([]string, error) {
+	environment, err := loadEnvFiles(config.EnvFiles)
+	for _, envVar := range config.EnvVars {
+		environment = append(environment, expandEnvironmentVariable(envVar, environment))
+	}
+	return environment, err
+}
+
+// ConfigProblems holds all problems found with a configuration
+type ConfigProblems []error
+
+// Error returns the problems as a readable list
+func (problems ConfigProblems) Error() string {
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, "  - "+problem.Error())
+	}
+	return fmt.Sprintf("found %v problem(s) with the configuration:\n%s", len(problems), strings.Join(messages, "\n"))
 }
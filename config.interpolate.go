@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// InterpolationDefaultDelimiter separates the name of a variable from
+// the value used when it's unset or empty (eg. ${PORT:-8080})
+const InterpolationDefaultDelimiter = ":-"
+
+// interpolationNamePattern matches names which can be interpolated,
+// anything else (eg. $1, $@) is left as-is
+var interpolationNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// shellReferencePattern matches a reference to a variable at the start
+// of a shell script, the operator is set for references such as
+// ${VAR:-default} which don't need the variable to be set
+var shellReferencePattern = regexp.MustCompile(`^\$(?:\{([A-Za-z_][A-Za-z0-9_]*)(:?[-=+?])?|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// shellAssignmentPatterns match the ways a shell script can assign a
+// variable itself, in which case it doesn't have to be set beforehand
+var shellAssignmentPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:^|[\s;&|(])([A-Za-z_][A-Za-z0-9_]*)=`),
+	regexp.MustCompile(`\bfor\s+([A-Za-z_][A-Za-z0-9_]*)\s+in\b`),
+	regexp.MustCompile(`\b(?:read|local|export|declare|readonly|typeset)((?:\s+-\w+)*(?:\s+[A-Za-z_][A-Za-z0-9_]*)+)`),
+}
+
+// ShellVariables are the variables which shells set themselves
+var ShellVariables = []string{
+	"HOME", "IFS", "LINENO", "OLDPWD", "OPTARG", "OPTIND", "PATH", "PPID", "PS1", "PS2", "PS4", "PWD",
+	"RANDOM", "REPLY", "SECONDS", "SHELL", "SHLVL", "UID", "EUID", "HOSTNAME",
+}
+
+// getGodevEnvironment returns the variables godev exports to every command,
+// execution groups before the final one get the staged build output
+func (config *Config) getGodevEnvironment(isFinalGroup bool) []string {
+	buildOutput := config.BuildOutput
+	if !isFinalGroup {
+		buildOutput = config.getStagedBuildOutput()
+	}
+	return []string{
+		EnvironmentBuildOutput + "=" + buildOutput,
+		EnvironmentWorkDirectory + "=" + config.WorkDirectory,
+		EnvironmentWatchDirectory + "=" + config.WatchDirectory,
+	}
+}
+
+// getInterpolationEnvironment returns the environment a command with the
+// :godevEnvironment and :environment runs with, which is what the
+// variables it references are resolved against
+func (config *Config) getInterpolationEnvironment(godevEnvironment []string, environment []string) []string {
+	provided := append(append([]string{}, godevEnvironment...), environment...)
+	composed, err := composeEnvironment(os.Environ(), config.EnvInherit, provided)
+	if err != nil {
+		composed, _ = composeEnvironment(nil, EnvironmentInheritNone, provided)
+	}
+	return composed
+}
+
+// interpolate expands $VAR, ${VAR} and ${VAR:-default} in each of the
+// :values using :environment. PipelineRunVariables are left for the
+// command to fill in when the run starts and $$ escapes a literal $
+func (config *Config) interpolate(values []string, environment []string) ([]string, error) {
+	var unknown, unsupported []string
+	var expand func(name string) string
+	expand = func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		reference := name
+		name, defaultValue, hasDefault := splitInterpolationDefault(reference)
+		if !interpolationNamePattern.MatchString(name) || (hasDefault && strings.ContainsAny(defaultValue, "{}")) {
+			if len(name) == 1 && !hasDefault {
+				return "$" + name
+			}
+			unsupported = append(unsupported, "${"+reference+"}")
+			return ""
+		} else if sliceContainsString(PipelineRunVariables, name) {
+			return "${" + name + "}"
+		}
+		if value, ok := lookupEnvironmentVariable(environment, name); ok && (len(value) > 0 || !hasDefault) {
+			return value
+		} else if hasDefault {
+			return os.Expand(defaultValue, expand)
+		}
+		unknown = append(unknown, name)
+		return ""
+	}
+	interpolated := []string{}
+	for _, value := range values {
+		interpolated = append(interpolated, os.Expand(value, expand))
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("unsupported reference(s) %s, only $VAR, ${VAR} and ${VAR%sdefault} are supported", strings.Join(unsupported, ", "), InterpolationDefaultDelimiter)
+	} else if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown variable(s) %s", strings.Join(unknown, ", "))
+	}
+	return interpolated, nil
+}
+
+// getUnknownShellReferences returns the variables which :script
+// references outside of single quotes without a default which are
+// neither in :environment nor set by the shell or the script itself
+func getUnknownShellReferences(script string, environment []string) []string {
+	assigned := map[string]bool{}
+	for _, pattern := range shellAssignmentPatterns {
+		for _, match := range pattern.FindAllStringSubmatch(script, -1) {
+			for _, name := range strings.Fields(match[1]) {
+				assigned[name] = true
+			}
+		}
+	}
+	var unknown []string
+	isSingleQuoted := false
+	isDoubleQuoted := false
+	for index := 0; index < len(script); index++ {
+		switch script[index] {
+		case '\\':
+			if !isSingleQuoted {
+				index++
+			}
+		case '\'':
+			if !isDoubleQuoted {
+				isSingleQuoted = !isSingleQuoted
+			}
+		case '"':
+			if !isSingleQuoted {
+				isDoubleQuoted = !isDoubleQuoted
+			}
+		case '$':
+			match := shellReferencePattern.FindStringSubmatch(script[index:])
+			if isSingleQuoted || match == nil || len(match[2]) > 0 {
+				continue
+			}
+			name := match[1] + match[3]
+			if _, ok := lookupEnvironmentVariable(environment, name); !ok &&
+				!assigned[name] &&
+				!sliceContainsString(ShellVariables, name) &&
+				!sliceContainsString(PipelineRunVariables, name) &&
+				!sliceContainsString(unknown, name) {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+	return unknown
+}
+
+// splitInterpolationDefault splits :name into the name of the variable
+// and the default value it was given with ${VAR:-default}
+func splitInterpolationDefault(name string) (string, string, bool) {
+	parts := strings.SplitN(name, InterpolationDefaultDelimiter, 2)
+	if len(parts) < 2 {
+		return name, "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// lookupEnvironmentVariable returns the value of :name in :environment
+// with the last definition winning
+func lookupEnvironmentVariable(environment []string, name string) (string, bool) {
+	for index := len(environment) - 1; index >= 0; index-- {
+		if key, value := splitEnvironmentVariable(environment[index]); key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
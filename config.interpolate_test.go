@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigInterpolateTestSuite struct {
+	suite.Suite
+	config *Config
+}
+
+func TestConfigInterpolateTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigInterpolateTestSuite))
+}
+
+func (s *ConfigInterpolateTestSuite) SetupTest() {
+	s.config = &Config{
+		BuildOutput:       "/work/bin/app",
+		CommandsDelimiter: ",",
+		WatchDirectory:    "/work/src",
+		WorkDirectory:     "/work",
+	}
+}
+
+func (s *ConfigInterpolateTestSuite) Test_getGodevEnvironment() {
+	assert.Equal(s.T(), []string{
+		"GODEV_BUILD_OUTPUT=/work/bin/app",
+		"GODEV_WORK_DIR=/work",
+		"GODEV_WATCH_DIR=/work/src",
+	}, s.config.getGodevEnvironment(true))
+}
+
+func (s *ConfigInterpolateTestSuite) Test_getGodevEnvironment_withSwap() {
+	t := s.T()
+	s.config.Swap = true
+	assert.Contains(t, s.config.getGodevEnvironment(false), "GODEV_BUILD_OUTPUT=/work/bin/app.next")
+	assert.Contains(t, s.config.getGodevEnvironment(true), "GODEV_BUILD_OUTPUT=/work/bin/app")
+}
+
+func (s *ConfigInterpolateTestSuite) Test_getInterpolationEnvironment() {
+	t := s.T()
+	os.Setenv("GODEV_TEST_INTERPOLATE", "from-os")
+	defer os.Unsetenv("GODEV_TEST_INTERPOLATE")
+	environment := s.config.getInterpolationEnvironment(s.config.getGodevEnvironment(true), []string{"GODEV_WORK_DIR=/elsewhere"})
+	value, _ := lookupEnvironmentVariable(environment, "GODEV_WORK_DIR")
+	assert.Equal(t, "/elsewhere", value)
+	value, _ = lookupEnvironmentVariable(environment, "GODEV_TEST_INTERPOLATE")
+	assert.Equal(t, "from-os", value)
+	s.config.EnvInherit = EnvironmentInheritNone
+	environment = s.config.getInterpolationEnvironment(s.config.getGodevEnvironment(true), nil)
+	_, ok := lookupEnvironmentVariable(environment, "GODEV_TEST_INTERPOLATE")
+	assert.False(t, ok)
+}
+
+func (s *ConfigInterpolateTestSuite) Test_interpolate() {
+	t := s.T()
+	interpolated, err := s.config.interpolate(
+		[]string{"go", "build", "-o", "${GODEV_BUILD_OUTPUT}", "$GODEV_WORK_DIR", "${A}", "x${A}y"},
+		append(s.config.getGodevEnvironment(true), "A=1", "A=2"),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"go", "build", "-o", "/work/bin/app", "/work", "2", "x2y"}, interpolated)
+}
+
+func (s *ConfigInterpolateTestSuite) Test_interpolate_leavesRunIDAndEscapes() {
+	t := s.T()
+	interpolated, err := s.config.interpolate([]string{"sh", "-c", "echo $$HOME $1 $GODEV_RUN_ID $GODEV_CHANGED_FILES"}, s.config.getGodevEnvironment(true))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sh", "-c", "echo $HOME $1 ${GODEV_RUN_ID} ${GODEV_CHANGED_FILES}"}, interpolated)
+	assert.Equal(t, "echo $HOME $1 3 ", InitPipelineRun(3, nil).Interpolate(interpolated[2]))
+}
+
+func (s *ConfigInterpolateTestSuite) Test_interpolate_withDefaults() {
+	t := s.T()
+	interpolated, err := s.config.interpolate(
+		[]string{"${UNSET:-8080}", "${EMPTY:-fallback}", "${SET:-unused}", "${UNSET:-$SET/bin}", "${UNSET:-}"},
+		[]string{"EMPTY=", "SET=value"},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"8080", "fallback", "value", "value/bin", ""}, interpolated)
+}
+
+func (s *ConfigInterpolateTestSuite) Test_interpolate_withUnsupportedReferences() {
+	t := s.T()
+	_, err := s.config.interpolate([]string{"${A-x}", "${A:=x}", "${A:-${B}}"}, []string{"A=1", "B=2"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unsupported reference(s) ${A-x}, ${A:=x}, ${A:-${B}, only $VAR, ${VAR} and ${VAR:-default} are supported")
+}
+
+func (s *ConfigInterpolateTestSuite) Test_interpolate_withUnknownVariables() {
+	t := s.T()
+	_, err := s.config.interpolate([]string{"echo", "${GODEV_TEST_UNKNOWN_A}", "$GODEV_TEST_UNKNOWN_B"}, s.config.getGodevEnvironment(true))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown variable(s) GODEV_TEST_UNKNOWN_A, GODEV_TEST_UNKNOWN_B")
+}
+
+func (s *ConfigInterpolateTestSuite) Test_getUnknownShellReferences() {
+	t := s.T()
+	assert.Empty(t, getUnknownShellReferences(
+		`for f in *.go; do gofmt -l "$f"; done; NAME=app; echo ${NAME} $HOME ${PORT:-8080} $GODEV_RUN_ID $1 $$ \$ESCAPED; awk '{print $NF}'`,
+		nil,
+	))
+	assert.Empty(t, getUnknownShellReferences(`read -r LINE; echo "$LINE $KNOWN"`, []string{"KNOWN=1"}))
+	assert.Equal(t, []string{"UNKNOWN", "ALSO_UNKNOWN"}, getUnknownShellReferences(`echo "$UNKNOWN" ${ALSO_UNKNOWN} '$QUOTED' "it's $UNKNOWN"`, nil))
+}
+
+func (s *ConfigInterpolateTestSuite) Test_getPipelineConfigs_interpolatesCommands() {
+	t := s.T()
+	s.config.EnvVars = []string{"PORT=8080", "MESSAGE=hello world"}
+	s.config.ExecGroups = []string{"${GODEV_BUILD_OUTPUT} --port '${PORT}' --message ${MESSAGE} ${HOST:-localhost},echo ${GODEV_TEST_UNKNOWN}"}
+	pipeline, err := s.config.getPipelineConfigs()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "execution group 1, command 2 ('echo ${GODEV_TEST_UNKNOWN}') could not be interpolated")
+	assert.Equal(t, "/work/bin/app", pipeline[0][0].Application)
+	assert.Equal(t, []string{"--port", "8080", "--message", "hello world", "localhost"}, pipeline[0][0].Arguments)
+	assert.Contains(t, pipeline[0][0].GodevEnvironment, "GODEV_WORK_DIR=/work")
+}
+
+func (s *ConfigInterpolateTestSuite) Test_getPipelineConfigs_checksShellCommands() {
+	t := s.T()
+	s.config.EnvVars = []string{"PORT=8080"}
+	s.config.ExecGroups = []string{"sh: echo $PORT $GODEV_BUILD_OUTPUT,sh: echo $GODEV_TEST_UNKNOWN"}
+	pipeline, err := s.config.getPipelineConfigs()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "execution group 1, command 2 ('sh: echo $GODEV_TEST_UNKNOWN') references unknown variable(s) GODEV_TEST_UNKNOWN")
+	assert.Len(t, pipeline[0], 1)
+	assert.Equal(t, []string{"-c", "echo $PORT $GODEV_BUILD_OUTPUT"}, pipeline[0][0].Arguments)
+}
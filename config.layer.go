@@ -0,0 +1,501 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/urfave/cli"
+)
+
+const (
+	// ConfigSourceDefault denotes a value from the built-in defaults
+	ConfigSourceDefault = "default"
+	// ConfigSourceFile denotes a value from the .godev.yml/.godev.toml
+	ConfigSourceFile = "file"
+	// ConfigSourceProfile denotes a value from the selected profile
+	ConfigSourceProfile = "profile"
+	// ConfigSourceEnvironment denotes a value from a GODEV_* environment variable
+	ConfigSourceEnvironment = "env"
+	// ConfigSourceFlag denotes a value from a command line flag
+	ConfigSourceFlag = "flag"
+)
+
+// ConfigEnvironmentPrefix is the prefix for environment variables
+// which godev reads its configuration from
+const ConfigEnvironmentPrefix = "GODEV_"
+
+// ConfigEnvironmentListDelimiter is the delimiter for environment
+// variables which hold values of flags that can be specified
+// multiple times (--env, --env-file and --exec)
+const ConfigEnvironmentListDelimiter = "\n"
+
+// ConfigLayer holds the configurations provided by a single source,
+// a nil/empty field means the source did not specify it. The keys
+// mirror the names of the flags and every field must be a pointer
+// or slice for merge() to work
+type ConfigLayer struct {
+	BuildOutput       *string          `yaml:"output" toml:"output"`
+	CommandArguments  []string         `yaml:"args" toml:"args"`
+	CommandsDelimiter *string          `yaml:"exec-delim" toml:"exec-delim"`
+	CrashLoopExits    *int             `yaml:"crash-loop-exits" toml:"crash-loop-exits"`
+	CrashLoopWindow   *string          `yaml:"crash-loop-window" toml:"crash-loop-window"`
+	EnvFiles          []string         `yaml:"env-file" toml:"env-file"`
+	EnvInherit        *string          `yaml:"env-inherit" toml:"env-inherit"`
+	EnvVars           []string         `yaml:"env" toml:"env"`
+	ExecGroups        ConfigExecGroups `yaml:"exec" toml:"exec"`
+	FileExtensions    []string         `yaml:"exts" toml:"exts"`
+	IgnoredNames      []string         `yaml:"ignore" toml:"ignore"`
+	LogsKeepRuns      *int             `yaml:"logs-keep-runs" toml:"logs-keep-runs"`
+	LogsMaxSize       *string          `yaml:"logs-max-size" toml:"logs-max-size"`
+	MaxParallel       *int             `yaml:"max-parallel" toml:"max-parallel"`
+	OutputMode        *string          `yaml:"output-mode" toml:"output-mode"`
+	PTY               *bool            `yaml:"pty" toml:"pty"`
+	Rate              *string          `yaml:"rate" toml:"rate"`
+	RestartAlways     *bool            `yaml:"restart-always" toml:"restart-always"`
+	RestartPolicy     *string          `yaml:"restart-policy" toml:"restart-policy"`
+	Shell             *string          `yaml:"shell" toml:"shell"`
+	Stdin             *bool            `yaml:"stdin" toml:"stdin"`
+	StopSignals       *string          `yaml:"stop-signals" toml:"stop-signals"`
+	Swap              *bool            `yaml:"swap" toml:"swap"`
+	WatchDirectory    *string          `yaml:"watch" toml:"watch"`
+	WorkDirectory     *string          `yaml:"dir" toml:"dir"`
+}
+
+// getConfigLayerFromFlags creates a configuration layer from flags
+// which were explicitly set on the command line
+func getConfigLayerFromFlags(c *cli.Context) (*ConfigLayer, error) {
+	layer := &ConfigLayer{}
+	if isFlagSet(c, "output", "o") {
+		value := c.String("output")
+		layer.BuildOutput = &value
+	}
+	if isFlagSet(c, "args") {
+		arguments, err := shellquote.Split(c.String("args"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse --args: %s", err)
+		}
+		layer.CommandArguments = arguments
+	}
+	if isFlagSet(c, "exec-delim") {
+		value := c.String("exec-delim")
+		layer.CommandsDelimiter = &value
+	}
+	if isFlagSet(c, "crash-loop-exits") {
+		value := c.Int("crash-loop-exits")
+		layer.CrashLoopExits = &value
+	}
+	if isFlagSet(c, "crash-loop-window") {
+		value := c.Duration("crash-loop-window").String()
+		layer.CrashLoopWindow = &value
+	}
+	if isFlagSet(c, "env-file") {
+		layer.EnvFiles = c.StringSlice("env-file")
+	}
+	if isFlagSet(c, "env-inherit") {
+		value := c.String("env-inherit")
+		layer.EnvInherit = &value
+	}
+	if isFlagSet(c, "env", "e") {
+		layer.EnvVars = c.StringSlice("env")
+	}
+	if isFlagSet(c, "exec") {
+		layer.ExecGroups = newConfigExecGroups(c.StringSlice("exec"))
+	}
+	if isFlagSet(c, "exts") {
+		layer.FileExtensions = strings.Split(c.String("exts"), ",")
+	}
+	if isFlagSet(c, "ignore") {
+		layer.IgnoredNames = strings.Split(c.String("ignore"), ",")
+	}
+	if isFlagSet(c, "logs-keep-runs") {
+		value := c.Int("logs-keep-runs")
+		layer.LogsKeepRuns = &value
+	}
+	if isFlagSet(c, "logs-max-size") {
+		value := c.String("logs-max-size")
+		layer.LogsMaxSize = &value
+	}
+	if isFlagSet(c, "max-parallel") {
+		value := c.Int("max-parallel")
+		layer.MaxParallel = &value
+	}
+	if isFlagSet(c, "output-mode") {
+		value := c.String("output-mode")
+		layer.OutputMode = &value
+	}
+	if isFlagSet(c, "pty") {
+		value := c.Bool("pty")
+		layer.PTY = &value
+	}
+	if isFlagSet(c, "rate") {
+		value := c.Duration("rate").String()
+		layer.Rate = &value
+	}
+	if isFlagSet(c, "restart-always") {
+		value := c.Bool("restart-always")
+		layer.RestartAlways = &value
+	}
+	if isFlagSet(c, "restart-policy") {
+		value := c.String("restart-policy")
+		layer.RestartPolicy = &value
+	}
+	if isFlagSet(c, "shell") {
+		value := c.String("shell")
+		layer.Shell = &value
+	}
+	if isFlagSet(c, "stdin") {
+		value := c.Bool("stdin")
+		layer.Stdin = &value
+	}
+	if isFlagSet(c, "stop-signals") {
+		value := c.String("stop-signals")
+		layer.StopSignals = &value
+	}
+	if isFlagSet(c, "swap") {
+		value := c.Bool("swap")
+		layer.Swap = &value
+	}
+	if isFlagSet(c, "watch") {
+		value := c.String("watch")
+		layer.WatchDirectory = &value
+	}
+	if isFlagSet(c, "dir") {
+		value := c.String("dir")
+		layer.WorkDirectory = &value
+	}
+	return layer, nil
+}
+
+// getConfigLayerFromEnvironment creates a configuration layer from
+// GODEV_* environment variables retrieved using :lookup
+func getConfigLayerFromEnvironment(lookup func(string) (string, bool)) (*ConfigLayer, error) {
+	layer := &ConfigLayer{}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "OUTPUT"); ok {
+		layer.BuildOutput = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "ARGS"); ok {
+		arguments, err := shellquote.Split(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %sARGS: %s", ConfigEnvironmentPrefix, err)
+		}
+		layer.CommandArguments = arguments
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "EXEC_DELIM"); ok {
+		layer.CommandsDelimiter = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "CRASH_LOOP_EXITS"); ok {
+		crashLoopExits, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %sCRASH_LOOP_EXITS: %s", ConfigEnvironmentPrefix, err)
+		}
+		layer.CrashLoopExits = &crashLoopExits
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "CRASH_LOOP_WINDOW"); ok {
+		layer.CrashLoopWindow = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "ENV_FILE"); ok {
+		layer.EnvFiles = splitNonEmpty(value, ConfigEnvironmentListDelimiter)
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "ENV_INHERIT"); ok {
+		layer.EnvInherit = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "ENV"); ok {
+		layer.EnvVars = splitNonEmpty(value, ConfigEnvironmentListDelimiter)
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "EXEC"); ok {
+		layer.ExecGroups = newConfigExecGroups(splitNonEmpty(value, ConfigEnvironmentListDelimiter))
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "EXTS"); ok {
+		layer.FileExtensions = splitNonEmpty(value, ",")
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "IGNORE"); ok {
+		layer.IgnoredNames = splitNonEmpty(value, ",")
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "LOGS_KEEP_RUNS"); ok {
+		logsKeepRuns, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %sLOGS_KEEP_RUNS: %s", ConfigEnvironmentPrefix, err)
+		}
+		layer.LogsKeepRuns = &logsKeepRuns
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "LOGS_MAX_SIZE"); ok {
+		layer.LogsMaxSize = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "MAX_PARALLEL"); ok {
+		maxParallel, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %sMAX_PARALLEL: %s", ConfigEnvironmentPrefix, err)
+		}
+		layer.MaxParallel = &maxParallel
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "OUTPUT_MODE"); ok {
+		layer.OutputMode = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "PTY"); ok {
+		usePTY, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %sPTY: %s", ConfigEnvironmentPrefix, err)
+		}
+		layer.PTY = &usePTY
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "RATE"); ok {
+		layer.Rate = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "RESTART_ALWAYS"); ok {
+		restartAlways, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %sRESTART_ALWAYS: %s", ConfigEnvironmentPrefix, err)
+		}
+		layer.RestartAlways = &restartAlways
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "RESTART_POLICY"); ok {
+		layer.RestartPolicy = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "SHELL"); ok {
+		layer.Shell = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "STDIN"); ok {
+		stdin, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %sSTDIN: %s", ConfigEnvironmentPrefix, err)
+		}
+		layer.Stdin = &stdin
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "STOP_SIGNALS"); ok {
+		layer.StopSignals = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "SWAP"); ok {
+		swap, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %sSWAP: %s", ConfigEnvironmentPrefix, err)
+		}
+		layer.Swap = &swap
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "WATCH"); ok {
+		layer.WatchDirectory = &value
+	}
+	if value, ok := lookup(ConfigEnvironmentPrefix + "DIR"); ok {
+		layer.WorkDirectory = &value
+	}
+	return layer, nil
+}
+
+// applyLayers resolves the configuration with the precedence of
+// flags > GODEV_* environment variables > selected profile > config
+// file, anything left unset is filled in by assignDefaults()
+func (config *Config) applyLayers(flags *ConfigLayer, environment *ConfigLayer) error {
+	workDirectory := getCurrentWorkingDirectory()
+	if environment.WorkDirectory != nil {
+		workDirectory = *environment.WorkDirectory
+	}
+	if flags.WorkDirectory != nil {
+		workDirectory = *flags.WorkDirectory
+	}
+	file, filePath, err := loadConfigFile(workDirectory)
+	if err != nil {
+		return err
+	}
+	config.ConfigFile = filePath
+	if err := config.applyLayer(&file.ConfigLayer, ConfigSourceFile); err != nil {
+		return fmt.Errorf("%s: %s", filePath, err)
+	}
+	if len(config.Profile) > 0 {
+		if len(filePath) == 0 {
+			return fmt.Errorf("profile '%s' was requested but no config file (%s) was found in '%s'", config.Profile, strings.Join(ConfigFileNames, "/"), workDirectory)
+		}
+		profile, err := file.GetProfile(config.Profile)
+		if err != nil {
+			return err
+		}
+		if err := config.applyLayer(profile, ConfigSourceProfile); err != nil {
+			return fmt.Errorf("%s: profile '%s': %s", filePath, config.Profile, err)
+		}
+	}
+	if err := config.applyLayer(environment, ConfigSourceEnvironment); err != nil {
+		return err
+	}
+	return config.applyLayer(flags, ConfigSourceFlag)
+}
+
+// applyLayer overrides the configuration with values specified in
+// :layer and records :source as where they came from
+func (config *Config) applyLayer(layer *ConfigLayer, source string) error {
+	if layer.BuildOutput != nil {
+		config.BuildOutput = *layer.BuildOutput
+		config.setSource("output", source)
+	}
+	// test mode runs its own execution groups unless a profile says otherwise
+	overridesTestMode := !config.RunTest || source == ConfigSourceProfile
+	if len(layer.CommandArguments) > 0 && overridesTestMode {
+		config.CommandArguments = layer.CommandArguments
+		config.setSource("args", source)
+	}
+	if layer.CommandsDelimiter != nil {
+		config.CommandsDelimiter = *layer.CommandsDelimiter
+		config.setSource("exec-delim", source)
+	}
+	if layer.CrashLoopExits != nil {
+		config.CrashLoopExits = *layer.CrashLoopExits
+		config.setSource("crash-loop-exits", source)
+	}
+	if layer.CrashLoopWindow != nil {
+		crashLoopWindow, err := time.ParseDuration(*layer.CrashLoopWindow)
+		if err != nil {
+			return fmt.Errorf("invalid crash-loop-window '%s': %s", *layer.CrashLoopWindow, err)
+		}
+		config.CrashLoopWindow = crashLoopWindow
+		config.setSource("crash-loop-window", source)
+	}
+	if len(layer.EnvFiles) > 0 {
+		config.EnvFiles = layer.EnvFiles
+		config.setSource("env-file", source)
+	}
+	if layer.EnvInherit != nil {
+		config.EnvInherit = *layer.EnvInherit
+		config.setSource("env-inherit", source)
+	}
+	if len(layer.EnvVars) > 0 {
+		config.EnvVars = layer.EnvVars
+		config.setSource("env", source)
+	}
+	if len(layer.ExecGroups) > 0 && overridesTestMode {
+		config.ExecGroups = layer.ExecGroups.Strings()
+		config.ExecGroupConfigs = layer.ExecGroups
+		config.setSource("exec", source)
+	}
+	if len(layer.FileExtensions) > 0 {
+		config.FileExtensions = layer.FileExtensions
+		config.setSource("exts", source)
+	}
+	if len(layer.IgnoredNames) > 0 {
+		config.IgnoredNames = layer.IgnoredNames
+		config.setSource("ignore", source)
+	}
+	if layer.LogsKeepRuns != nil {
+		config.LogsKeepRuns = *layer.LogsKeepRuns
+		config.setSource("logs-keep-runs", source)
+	}
+	if layer.LogsMaxSize != nil {
+		config.LogsMaxSize = *layer.LogsMaxSize
+		config.setSource("logs-max-size", source)
+	}
+	if layer.MaxParallel != nil {
+		config.MaxParallel = *layer.MaxParallel
+		config.setSource("max-parallel", source)
+	}
+	if layer.OutputMode != nil {
+		config.OutputMode = *layer.OutputMode
+		config.setSource("output-mode", source)
+	}
+	if layer.PTY != nil {
+		config.PTY = *layer.PTY
+		config.setSource("pty", source)
+	}
+	if layer.Rate != nil {
+		rate, err := time.ParseDuration(*layer.Rate)
+		if err != nil {
+			return fmt.Errorf("invalid rate '%s': %s", *layer.Rate, err)
+		}
+		config.Rate = rate
+		config.setSource("rate", source)
+	}
+	if layer.RestartAlways != nil {
+		config.RestartAlways = *layer.RestartAlways
+		config.setSource("restart-always", source)
+	}
+	if layer.RestartPolicy != nil {
+		config.RestartPolicy = *layer.RestartPolicy
+		config.setSource("restart-policy", source)
+	}
+	if layer.Shell != nil {
+		config.Shell = *layer.Shell
+		config.setSource("shell", source)
+	}
+	if layer.Stdin != nil {
+		config.Stdin = *layer.Stdin
+		config.setSource("stdin", source)
+	}
+	if layer.StopSignals != nil {
+		config.StopSignals = *layer.StopSignals
+		config.setSource("stop-signals", source)
+	}
+	if layer.Swap != nil {
+		config.Swap = *layer.Swap
+		config.setSource("swap", source)
+	}
+	if layer.WatchDirectory != nil {
+		config.WatchDirectory = *layer.WatchDirectory
+		config.setSource("watch", source)
+	}
+	if layer.WorkDirectory != nil {
+		config.WorkDirectory = *layer.WorkDirectory
+		config.setSource("dir", source)
+	}
+	return nil
+}
+
+// merge overrides the fields in :layer with those specified in :override
+func (layer *ConfigLayer) merge(override *ConfigLayer) {
+	layerValue := reflect.ValueOf(layer).Elem()
+	overrideValue := reflect.ValueOf(override).Elem()
+	for index := 0; index < overrideValue.NumField(); index++ {
+		if field := overrideValue.Field(index); !field.IsNil() {
+			layerValue.Field(index).Set(field)
+		}
+	}
+}
+
+// resolvePaths resolves relative paths against :baseDirectory
+func (layer *ConfigLayer) resolvePaths(baseDirectory string) {
+	if layer.WorkDirectory != nil {
+		workDirectory := resolvePath(baseDirectory, *layer.WorkDirectory)
+		layer.WorkDirectory = &workDirectory
+	}
+	if layer.WatchDirectory != nil {
+		watchDirectory := resolvePath(baseDirectory, *layer.WatchDirectory)
+		layer.WatchDirectory = &watchDirectory
+	}
+	for index, envFile := range layer.EnvFiles {
+		layer.EnvFiles[index] = resolvePath(baseDirectory, envFile)
+	}
+}
+
+// setSource records :source as the origin of the configuration :key
+func (config *Config) setSource(key string, source string) {
+	if config.Sources == nil {
+		config.Sources = map[string]string{}
+	}
+	config.Sources[key] = source
+}
+
+// setDefaultSource records :key as coming from the built-in defaults
+// if no other source has specified it
+func (config *Config) setDefaultSource(key string) {
+	if _, ok := config.Sources[key]; !ok {
+		config.setSource(key, ConfigSourceDefault)
+	}
+}
+
+// isFlagSet checks whether any of the :names of a flag were set
+func isFlagSet(c *cli.Context, names ...string) bool {
+	for _, name := range names {
+		if c.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath returns :pathToResolve relative to :base if it
+// isn't already absolute
+func resolvePath(base string, pathToResolve string) string {
+	if path.IsAbs(pathToResolve) {
+		return pathToResolve
+	}
+	return path.Join(base, pathToResolve)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigLayerTestSuite struct {
+	suite.Suite
+}
+
+func TestConfigLayerTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigLayerTestSuite))
+}
+
+func (s *ConfigLayerTestSuite) Test_getConfigLayerFromEnvironment() {
+	t := s.T()
+	environment := map[string]string{
+		"GODEV_ARGS":             "--port '8080'",
+		"GODEV_CRASH_LOOP_EXITS": "3",
+		"GODEV_EXEC":             "go build -o bin/app\nbin/app",
+		"GODEV_EXTS":             "go,sql",
+		"GODEV_LOGS_KEEP_RUNS":   "5",
+		"GODEV_LOGS_MAX_SIZE":    "10MB",
+		"GODEV_MAX_PARALLEL":     "2",
+		"GODEV_OUTPUT_MODE":      "prefixed",
+		"GODEV_PTY":              "1",
+		"GODEV_STDIN":            "true",
+		"GODEV_RATE":             "5s",
+		"GODEV_RESTART_POLICY":   "on-failure",
+		"GODEV_SWAP":             "true",
+		"GODEV_WATCH":            "/some/path/to/watch",
+	}
+	layer, err := getConfigLayerFromEnvironment(func(key string) (string, bool) {
+		value, ok := environment[key]
+		return value, ok
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"--port", "8080"}, layer.CommandArguments)
+	assert.Equal(t, []string{"go build -o bin/app", "bin/app"}, layer.ExecGroups.Strings())
+	assert.Equal(t, []string{"go", "sql"}, layer.FileExtensions)
+	assert.Equal(t, "5s", *layer.Rate)
+	assert.Equal(t, 3, *layer.CrashLoopExits)
+	assert.Equal(t, 5, *layer.LogsKeepRuns)
+	assert.Equal(t, "10MB", *layer.LogsMaxSize)
+	assert.Equal(t, 2, *layer.MaxParallel)
+	assert.Equal(t, OutputModePrefixed, *layer.OutputMode)
+	assert.True(t, *layer.PTY)
+	assert.True(t, *layer.Stdin)
+	assert.Equal(t, RestartPolicyOnFailure, *layer.RestartPolicy)
+	assert.True(t, *layer.Swap)
+	assert.Equal(t, "/some/path/to/watch", *layer.WatchDirectory)
+	assert.Nil(t, layer.BuildOutput)
+	assert.Nil(t, layer.EnvVars)
+}
+
+func (s *ConfigLayerTestSuite) Test_getConfigLayerFromEnvironment_invalidSwap() {
+	_, err := getConfigLayerFromEnvironment(func(key string) (string, bool) {
+		return "sometimes", key == "GODEV_SWAP"
+	})
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "unable to parse GODEV_SWAP")
+}
+
+func (s *ConfigLayerTestSuite) Test_getConfigLayerFromEnvironment_invalidCrashLoopExits() {
+	_, err := getConfigLayerFromEnvironment(func(key string) (string, bool) {
+		return "many", key == "GODEV_CRASH_LOOP_EXITS"
+	})
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "unable to parse GODEV_CRASH_LOOP_EXITS")
+}
+
+func (s *ConfigLayerTestSuite) Test_getConfigLayerFromEnvironment_invalidStdin() {
+	_, err := getConfigLayerFromEnvironment(func(key string) (string, bool) {
+		return "please", key == "GODEV_STDIN"
+	})
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "unable to parse GODEV_STDIN")
+}
+
+func (s *ConfigLayerTestSuite) Test_getConfigLayerFromEnvironment_invalidPTY() {
+	_, err := getConfigLayerFromEnvironment(func(key string) (string, bool) {
+		return "maybe", key == "GODEV_PTY"
+	})
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "unable to parse GODEV_PTY")
+}
+
+func (s *ConfigLayerTestSuite) Test_getConfigLayerFromEnvironment_invalidMaxParallel() {
+	_, err := getConfigLayerFromEnvironment(func(key string) (string, bool) {
+		return "all", key == "GODEV_MAX_PARALLEL"
+	})
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "unable to parse GODEV_MAX_PARALLEL")
+}
+
+func (s *ConfigLayerTestSuite) Test_getConfigLayerFromEnvironment_invalidLogsKeepRuns() {
+	_, err := getConfigLayerFromEnvironment(func(key string) (string, bool) {
+		return "many", key == "GODEV_LOGS_KEEP_RUNS"
+	})
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "unable to parse GODEV_LOGS_KEEP_RUNS")
+}
+
+func (s *ConfigLayerTestSuite) Test_applyLayers_precedence() {
+	t := s.T()
+	workDirectory := path.Join(getCurrentWorkingDirectory(), "/data/test-config/yml")
+	environmentRate := "4s"
+	environmentOutput := "bin/from-env"
+	flagOutput := "bin/from-flag"
+	config := &Config{}
+	err := config.applyLayers(
+		&ConfigLayer{
+			BuildOutput:   &flagOutput,
+			WorkDirectory: &workDirectory,
+		},
+		&ConfigLayer{
+			BuildOutput: &environmentOutput,
+			Rate:        &environmentRate,
+		},
+	)
+	assert.Nil(t, err)
+	config.assignDefaults()
+	assert.Equal(t, path.Join(workDirectory, "/.godev.yml"), config.ConfigFile)
+	assert.Equal(t, path.Join(workDirectory, "/bin/from-flag"), config.BuildOutput)
+	assert.Equal(t, ConfigSourceFlag, config.Sources["output"])
+	assert.Equal(t, 4*time.Second, config.Rate)
+	assert.Equal(t, ConfigSourceEnvironment, config.Sources["rate"])
+	assert.Equal(t, []string{"go", "yml"}, []string(config.FileExtensions))
+	assert.Equal(t, ConfigSourceFile, config.Sources["exts"])
+	assert.Equal(t, ",", config.CommandsDelimiter)
+	assert.Equal(t, ConfigSourceDefault, config.Sources["exec-delim"])
+}
+
+func (s *ConfigLayerTestSuite) Test_applyLayer_invalidRate() {
+	rate := "soon"
+	err := (&Config{}).applyLayer(&ConfigLayer{Rate: &rate}, ConfigSourceFile)
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "invalid rate")
+}
+
+func (s *ConfigLayerTestSuite) Test_applyLayer_invalidCrashLoopWindow() {
+	window := "a while"
+	err := (&Config{}).applyLayer(&ConfigLayer{CrashLoopWindow: &window}, ConfigSourceFile)
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "invalid crash-loop-window")
+}
+
+func (s *ConfigLayerTestSuite) Test_applyLayer_testModeIgnoresExecGroups() {
+	config := &Config{RunTest: true}
+	config.applyLayer(&ConfigLayer{ExecGroups: newConfigExecGroups([]string{"bin/app"})}, ConfigSourceFile)
+	assert.Len(s.T(), config.ExecGroups, 0)
+}
+
+func (s *ConfigLayerTestSuite) Test_applyLayers_withProfile() {
+	t := s.T()
+	workDirectory := path.Join(getCurrentWorkingDirectory(), "/data/test-config/profiles")
+	config := &Config{Profile: "worker", RunTest: true}
+	err := config.applyLayers(&ConfigLayer{WorkDirectory: &workDirectory}, &ConfigLayer{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"go build -o bin/app", "bin/app worker"}, []string(config.ExecGroups))
+	assert.Equal(t, ConfigSourceProfile, config.Sources["exec"])
+	assert.Equal(t, []string{"go"}, []string(config.FileExtensions))
+	assert.Equal(t, ConfigSourceFile, config.Sources["exts"])
+}
+
+func (s *ConfigLayerTestSuite) Test_applyLayers_withProfileButNoFile() {
+	workDirectory := path.Join(getCurrentWorkingDirectory(), "/data/test-config")
+	config := &Config{Profile: "worker"}
+	err := config.applyLayers(&ConfigLayer{WorkDirectory: &workDirectory}, &ConfigLayer{})
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "no config file")
+}
+
+func (s *ConfigLayerTestSuite) Test_merge() {
+	baseOutput := "bin/base"
+	overrideOutput := "bin/override"
+	layer := &ConfigLayer{BuildOutput: &baseOutput, ExecGroups: newConfigExecGroups([]string{"base"})}
+	layer.merge(&ConfigLayer{BuildOutput: &overrideOutput})
+	assert.Equal(s.T(), "bin/override", *layer.BuildOutput)
+	assert.Equal(s.T(), []string{"base"}, layer.ExecGroups.Strings())
+}
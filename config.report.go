@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	shellquote "github.com/kballard/go-shellquote"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigReport is the resolved configuration printed by `godev config print`
+type ConfigReport struct {
+	ConfigFile string                       `json:"config_file" yaml:"config_file"`
+	Profile    string                       `json:"profile" yaml:"profile"`
+	Mode       string                       `json:"mode" yaml:"mode"`
+	Values     map[string]ConfigReportValue `json:"values" yaml:"values"`
+	Pipeline   [][]string                   `json:"pipeline" yaml:"pipeline"`
+}
+
+// ConfigReportValue holds a resolved value and the layer it came from
+type ConfigReportValue struct {
+	Value  interface{} `json:"value" yaml:"value"`
+	Source string      `json:"source" yaml:"source"`
+}
+
+// GetReport creates a report of the resolved configuration
+func (config *Config) GetReport() *ConfigReport {
+	report := &ConfigReport{
+		ConfigFile: config.ConfigFile,
+		Profile:    config.Profile,
+		Mode:       "default",
+		Values:     map[string]ConfigReportValue{},
+		Pipeline:   [][]string{},
+	}
+	if config.RunTest {
+		report.Mode = "test"
+	}
+	values := map[string]interface{}{
+		"output":            config.BuildOutput,
+		"output-mode":       config.OutputMode,
+		"args":              nonNilStrings(config.CommandArguments),
+		"exec-delim":        config.CommandsDelimiter,
+		"crash-loop-exits":  config.CrashLoopExits,
+		"crash-loop-window": config.CrashLoopWindow.String(),
+		"env":               nonNilStrings(config.EnvVars),
+		"env-file":          nonNilStrings(config.EnvFiles),
+		"env-inherit":       config.EnvInherit,
+		"exec":              nonNilStrings(config.ExecGroups),
+		"exts":              nonNilStrings(config.FileExtensions),
+		"ignore":            nonNilStrings(config.IgnoredNames),
+		"logs-keep-runs":    config.LogsKeepRuns,
+		"logs-max-size":     config.LogsMaxSize,
+		"max-parallel":      config.MaxParallel,
+		"pty":               config.PTY,
+		"rate":              config.Rate.String(),
+		"restart-always":    config.RestartAlways,
+		"restart-policy":    config.RestartPolicy,
+		"shell":             config.Shell,
+		"stdin":             config.Stdin,
+		"stop-signals":      config.StopSignals,
+		"swap":              config.Swap,
+		"watch":             config.WatchDirectory,
+		"dir":               config.WorkDirectory,
+	}
+	for key, value := range values {
+		report.Values[key] = ConfigReportValue{Value: value, Source: config.Sources[key]}
+	}
+	pipelineConfigs, _ := config.getPipelineConfigs()
+	for _, commandConfigs := range pipelineConfigs {
+		group := []string{}
+		for _, commandConfig := range commandConfigs {
+			group = append(group, shellquote.Join(append([]string{commandConfig.Application}, commandConfig.Arguments...)...))
+		}
+		report.Pipeline = append(report.Pipeline, group)
+	}
+	return report
+}
+
+// Marshal renders the report in :format which is one of 'yaml' or 'json'
+func (report *ConfigReport) Marshal(format string) ([]byte, error) {
+	switch format {
+	case "yaml", "yml":
+		return yaml.Marshal(report)
+	case "json":
+		return json.MarshalIndent(report, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported format '%s' (use 'yaml' or 'json')", format)
+	}
+}
+
+// nonNilStrings ensures empty lists are rendered as such instead of as null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
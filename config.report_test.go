@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigReportTestSuite struct {
+	suite.Suite
+}
+
+func TestConfigReportTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigReportTestSuite))
+}
+
+func (s *ConfigReportTestSuite) TestGetReport() {
+	t := s.T()
+	c := &Config{
+		CommandArguments:  []string{"--port", "8080"},
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"go build -o bin/app,go vet ./...", "bin/app 'a b'"},
+		Profile:           "api",
+		Rate:              time.Second,
+		Sources:           map[string]string{"exec": ConfigSourceFile},
+	}
+	report := c.GetReport()
+	assert.Equal(t, "api", report.Profile)
+	assert.Equal(t, "default", report.Mode)
+	assert.Equal(t, ConfigSourceFile, report.Values["exec"].Source)
+	assert.Equal(t, "1s", report.Values["rate"].Value)
+	assert.Equal(t, [][]string{
+		{"go build -o bin/app", "go vet ./..."},
+		{"bin/app 'a b' --port 8080"},
+	}, report.Pipeline)
+}
+
+func (s *ConfigReportTestSuite) TestMarshal() {
+	report := (&Config{CommandsDelimiter: ","}).GetReport()
+	output, err := report.Marshal("yaml")
+	assert.Nil(s.T(), err)
+	assert.Contains(s.T(), string(output), "pipeline: []")
+	output, err = report.Marshal("json")
+	assert.Nil(s.T(), err)
+	assert.Contains(s.T(), string(output), `"pipeline": []`)
+	_, err = report.Marshal("xml")
+	assert.NotNil(s.T(), err)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate checks the configuration for problems which would stop
+// the pipeline from running and returns all of them as ConfigProblems
+func (config *Config) Validate() error {
+	var problems ConfigProblems
+	if !directoryExists(config.WorkDirectory) {
+		problems = append(problems, fmt.Errorf("work directory '%s' does not exist", config.WorkDirectory))
+	}
+	if !directoryExists(config.WatchDirectory) {
+		problems = append(problems, fmt.Errorf("watch directory '%s' does not exist", config.WatchDirectory))
+	}
+	pipelineConfigs, err := config.getPipelineConfigs()
+	if err != nil {
+		problems = append(problems, err.(ConfigProblems)...)
+	}
+	for execGroupIndex, commandConfigs := range pipelineConfigs {
+		for commandIndex, commandConfig := range commandConfigs {
+			if commandConfig.Application == config.BuildOutput {
+				// the build output only exists after the pipeline has run
+				continue
+			}
+			if err := InitCommand(commandConfig).IsValid(); err != nil {
+				problems = append(problems, fmt.Errorf(
+					"execution group %v, command %v ('%s'): %s",
+					execGroupIndex+1,
+					commandIndex+1,
+					strings.TrimSpace(fmt.Sprintf("%s %s", commandConfig.Application, strings.Join(commandConfig.Arguments, " "))),
+					err,
+				))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
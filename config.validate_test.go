@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigValidateTestSuite struct {
+	suite.Suite
+}
+
+func TestConfigValidateTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigValidateTestSuite))
+}
+
+func (s *ConfigValidateTestSuite) TestValidate() {
+	c := &Config{
+		BuildOutput:       "/some/path/to/work/bin/app",
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"go version", "go build -o /some/path/to/work/bin/app", "/some/path/to/work/bin/app"},
+		WatchDirectory:    getCurrentWorkingDirectory(),
+		WorkDirectory:     getCurrentWorkingDirectory(),
+	}
+	assert.Nil(s.T(), c.Validate())
+}
+
+func (s *ConfigValidateTestSuite) TestValidate_withProblems() {
+	t := s.T()
+	c := &Config{
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"go version,this-does-not-exist", "echo 'unterminated"},
+		WatchDirectory:    "/does/and/should/not/exist",
+		WorkDirectory:     getCurrentWorkingDirectory(),
+	}
+	err := c.Validate()
+	assert.NotNil(t, err)
+	assert.Len(t, err.(ConfigProblems), 3)
+	assert.Contains(t, err.Error(), "watch directory '/does/and/should/not/exist' does not exist")
+	assert.Contains(t, err.Error(), "execution group 1, command 2 ('this-does-not-exist')")
+	assert.Contains(t, err.Error(), "execution group 2, command 1 ('echo 'unterminated') could not be parsed")
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -31,6 +33,58 @@ func (s *ConfigTestSuite) Test_assignDefaultsRun() {
 	assert.Equal(t, "go mod vendor", c.ExecGroups[0])
 	assert.Equal(t, "go build -o /some/path/to/work/bin/app", c.ExecGroups[1])
 	assert.Equal(t, "/some/path/to/work/bin/app", c.ExecGroups[2])
+	assert.Equal(t, DefaultStopSignals, c.StopSignals)
+	assert.Equal(t, RestartPolicyNever, c.RestartPolicy)
+	assert.Equal(t, DefaultCrashLoopExits, c.CrashLoopExits)
+	assert.Equal(t, DefaultCrashLoopWindow, c.CrashLoopWindow)
+	assert.Equal(t, DefaultMaxParallel, c.MaxParallel)
+	assert.Equal(t, OutputModeRaw, c.OutputMode)
+	assert.Equal(t, DefaultLogsKeepRuns, c.LogsKeepRuns)
+	assert.Equal(t, DefaultLogsMaxSize, c.LogsMaxSize)
+}
+
+func (s *ConfigTestSuite) Test_assignDefaultsRunWithLogsDisabled() {
+	t := s.T()
+	c := &Config{
+		LogsKeepRuns:  0,
+		Sources:       map[string]string{"logs-keep-runs": ConfigSourceFlag},
+		WorkDirectory: "/some/path/to/work",
+	}
+	c.assignDefaults()
+	assert.Equal(t, 0, c.LogsKeepRuns)
+	assert.Nil(t, c.getRunLogs())
+}
+
+func (s *ConfigTestSuite) Test_getRunLogs() {
+	t := s.T()
+	c := &Config{
+		LogsKeepRuns:  5,
+		LogsMaxSize:   "2KB",
+		WorkDirectory: "/some/path/to/work",
+	}
+	logs := c.getRunLogs()
+	_, err := time.Parse(RunLogsSessionFormat, logs.Session)
+	assert.Nil(t, err)
+	assert.Equal(t, &RunLogs{
+		Directory: "/some/path/to/work/.godev/logs",
+		Session:   logs.Session,
+		MaxRuns:   5,
+		MaxSize:   2048,
+	}, logs)
+}
+
+func (s *ConfigTestSuite) Test_assignDefaultsRunWithSwap() {
+	t := s.T()
+	c := &Config{
+		BuildOutput:    "bin/app",
+		Swap:           true,
+		WatchDirectory: "/some/path/to/watch",
+		WorkDirectory:  "/some/path/to/work",
+	}
+	c.assignDefaults()
+	assert.Equal(t, "go build -o /some/path/to/work/bin/app.next", c.ExecGroups[1])
+	assert.Equal(t, "/some/path/to/work/bin/app", c.ExecGroups[2])
+	assert.Equal(t, "/some/path/to/work/bin/app.next", c.getStagedBuildOutput())
 }
 
 func (s *ConfigTestSuite) Test_assignDefaultsTest() {
@@ -52,6 +106,21 @@ func (s *ConfigTestSuite) Test_assignDefaultsTest() {
 	assert.Equal(t, "go test ./... -coverprofile c.out", c.ExecGroups[2])
 }
 
+func (s *ConfigTestSuite) Test_assignDefaultsKeepsSetValues() {
+	t := s.T()
+	c := &Config{
+		CommandsDelimiter: ";",
+		ExecGroups:        []string{"make build", "bin/app"},
+		Rate:              5 * time.Second,
+		WorkDirectory:     "/some/path/to/work",
+	}
+	c.assignDefaults()
+	assert.Equal(t, "/some/path/to/work/bin/app", c.BuildOutput)
+	assert.Equal(t, ";", c.CommandsDelimiter)
+	assert.Equal(t, []string{"make build", "bin/app"}, []string(c.ExecGroups))
+	assert.Equal(t, 5*time.Second, c.Rate)
+}
+
 func (s *ConfigTestSuite) Test_interpretLogLevel() {
 	c := &Config{LogVerbose: true}
 	c.interpretLogLevel()
@@ -60,3 +129,113 @@ func (s *ConfigTestSuite) Test_interpretLogLevel() {
 	c.interpretLogLevel()
 	assert.Equal(s.T(), "trace", c.LogLevel.String())
 }
+
+func (s *ConfigTestSuite) Test_getCommandEnvironment() {
+	c := &Config{
+		EnvFiles: []string{path.Join(getCurrentWorkingDirectory(), "/data/test-env/override.env")},
+		EnvVars:  []string{"APP_ENV=test"},
+	}
+	environment, err := c.getCommandEnvironment()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{"APP_ENV=production", "APP_TITLE= in production", "APP_ENV=test"}, environment)
+}
+
+func (s *ConfigTestSuite) Test_getPipelineConfigs_withShell() {
+	t := s.T()
+	c := &Config{
+		CommandArguments:  []string{"--port", "80 80"},
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"go build ./... && echo ${UNKNOWN_TO_GODEV:-none} | tee out.log", "bin/app"},
+		Shell:             "/bin/bash",
+	}
+	pipeline, err := c.getPipelineConfigs()
+	assert.Nil(t, err)
+	assert.Equal(t, "/bin/bash", pipeline[0][0].Application)
+	assert.Equal(t, []string{"-c", "go build ./... && echo ${UNKNOWN_TO_GODEV:-none} | tee out.log"}, pipeline[0][0].Arguments)
+	assert.Equal(t, []string{"-c", "bin/app --port '80 80'"}, pipeline[1][0].Arguments)
+}
+
+func (s *ConfigTestSuite) Test_getPipelineConfigs_withShellPrefix() {
+	t := s.T()
+	c := &Config{
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"sh: ls *.go | wc -l,echo 'a b',sh:  "},
+	}
+	pipeline, err := c.getPipelineConfigs()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "execution group 1, command 3 is empty")
+	assert.Equal(t, ShellCommandPrefixShell, pipeline[0][0].Application)
+	assert.Equal(t, []string{"-c", "ls *.go | wc -l"}, pipeline[0][0].Arguments)
+	assert.Equal(t, "echo", pipeline[0][1].Application)
+	assert.Equal(t, []string{"a b"}, pipeline[0][1].Arguments)
+}
+
+func (s *ConfigTestSuite) Test_getPipelineConfigs_withStopSignals() {
+	t := s.T()
+	c := &Config{
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"bin/app"},
+		StopSignals:       "SIGTERM,SIGKILL:3s",
+	}
+	pipeline, err := c.getPipelineConfigs()
+	assert.Nil(t, err)
+	assert.Equal(t, "SIGTERM,SIGKILL:3s", pipeline[0][0].StopSignals.String())
+	c.StopSignals = "SIGTERM,SIGNONE"
+	_, err = c.getPipelineConfigs()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid --stop-signals 'SIGTERM,SIGNONE': unknown stop signal 'SIGNONE'")
+}
+
+func (s *ConfigTestSuite) Test_getPipelineConfigs_withRestartPolicy() {
+	t := s.T()
+	c := &Config{
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"bin/app"},
+		RestartPolicy:     RestartPolicyOnFailure,
+	}
+	_, err := c.getPipelineConfigs()
+	assert.Nil(t, err)
+	c.RestartPolicy = "sometimes"
+	c.CrashLoopExits = -1
+	c.MaxParallel = -1
+	c.OutputMode = "fancy"
+	c.LogsKeepRuns = -1
+	c.LogsMaxSize = "lots"
+	_, err = c.getPipelineConfigs()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid --restart-policy 'sometimes': should be one of 'never', 'on-failure' or 'always'")
+	assert.Contains(t, err.Error(), "invalid --crash-loop-exits '-1'")
+	assert.Contains(t, err.Error(), "invalid --max-parallel '-1'")
+	assert.Contains(t, err.Error(), "invalid --output-mode 'fancy': should be one of 'raw', 'prefixed' or 'grouped'")
+	assert.Contains(t, err.Error(), "invalid --logs-keep-runs '-1'")
+	assert.Contains(t, err.Error(), "invalid --logs-max-size 'lots'")
+}
+
+func (s *ConfigTestSuite) Test_getPipelineConfigs_withTimeoutAndRetries() {
+	t := s.T()
+	c := &Config{
+		CommandsDelimiter: ",",
+		ExecGroupConfigs: ConfigExecGroups{
+			{Commands: []*ConfigExecCommand{{Run: "go test ./...", Timeout: "5m", Retries: 2}, {Run: "sh: go vet ./...", Timeout: "1m"}}},
+		},
+	}
+	pipeline, err := c.getPipelineConfigs()
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, pipeline[0][0].Timeout)
+	assert.Equal(t, 2, pipeline[0][0].Retries)
+	assert.Equal(t, time.Minute, pipeline[0][1].Timeout)
+	assert.Equal(t, 0, pipeline[0][1].Retries)
+}
+
+func (s *ConfigTestSuite) Test_getPipelineConfigs_withPTY() {
+	t := s.T()
+	c := &Config{
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"go test ./...,sh: go vet ./..."},
+		PTY:               true,
+	}
+	pipeline, err := c.getPipelineConfigs()
+	assert.Nil(t, err)
+	assert.True(t, pipeline[0][0].PTY)
+	assert.True(t, pipeline[0][1].PTY)
+}
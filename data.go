@@ -207,8 +207,9 @@ log.error:
 `
 
 // DataDotGitignore defines the '.gitignore' contents when --init is used
-// hash:3e59a1165602d77a63163af48e9793bc
+// hash:aa1ff46036e14de854a81acea4c1d669
 const DataDotGitignore = `# development artifacts
+.godev
 bin
 c.out
 vendor
@@ -216,9 +217,10 @@ vendor
 `
 
 // DataDotDockerignore defines the '.dockerignore' contents when --init is used
-// hash:9441e48bcf7b0249fc852973e74053f4
+// hash:39998d7cf0a67a1f46095bd14d09734c
 const DataDotDockerignore = `.dockerignore
 .gitignore
+.godev
 Dockerfile
 bin
 c.out
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// dotEnvKeyPattern matches valid variable names in a dotenv file
+var dotEnvKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// loadEnvFiles parses the dotenv files at :pathsToFiles in order and
+// returns their variables as KEY=VALUE pairs, variables from later
+// files can reference those from earlier ones
+func loadEnvFiles(pathsToFiles []string) ([]string, error) {
+	var environment []string
+	values := map[string]string{}
+	for _, pathToFile := range pathsToFiles {
+		data, err := ioutil.ReadFile(pathToFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read env file: %s", err)
+		}
+		variables, err := parseDotEnv(string(data), func(key string) (string, bool) {
+			if value, ok := values[key]; ok {
+				return value, true
+			}
+			return os.LookupEnv(key)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse env file '%s': %s", pathToFile, err)
+		}
+		for _, variable := range variables {
+			keyValue := strings.SplitN(variable, "=", 2)
+			values[keyValue[0]] = keyValue[1]
+		}
+		environment = append(environment, variables...)
+	}
+	return environment, nil
+}
+
+// parseDotEnv parses :data in dotenv syntax into KEY=VALUE pairs, ${VAR}
+// and $VAR in unquoted and double-quoted values are expanded using
+// variables defined earlier in :data, falling back to :lookup
+func parseDotEnv(data string, lookup func(string) (string, bool)) ([]string, error) {
+	var environment []string
+	values := map[string]string{}
+	expand := func(value string) string {
+		return os.Expand(value, func(key string) string {
+			if expanded, ok := values[key]; ok {
+				return expanded
+			}
+			expanded, _ := lookup(key)
+			return expanded
+		})
+	}
+	for index, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		keyValue := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(keyValue[0])
+		if len(keyValue) != 2 || !dotEnvKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %v: expected KEY=VALUE but got '%s'", index+1, line)
+		}
+		value, err := parseDotEnvValue(strings.TrimSpace(keyValue[1]), expand)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %s", index+1, err)
+		}
+		values[key] = value
+		environment = append(environment, key+"="+value)
+	}
+	return environment, nil
+}
+
+// parseDotEnvValue handles the quoting of a single dotenv value
+func parseDotEnvValue(value string, expand func(string) string) (string, error) {
+	if strings.HasPrefix(value, "'") {
+		closingIndex := strings.Index(value[1:], "'")
+		if closingIndex < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value %s", value)
+		}
+		return value[1 : closingIndex+1], nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		var unquoted strings.Builder
+		for index := 1; index < len(value); index++ {
+			switch character := value[index]; {
+			case character == '\\' && index+1 < len(value):
+				index++
+				switch value[index] {
+				case 'n':
+					unquoted.WriteByte('\n')
+				case 't':
+					unquoted.WriteByte('\t')
+				default:
+					unquoted.WriteByte(value[index])
+				}
+			case character == '"':
+				return expand(unquoted.String()), nil
+			default:
+				unquoted.WriteByte(character)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value %s", value)
+	}
+	if commentIndex := strings.Index(value, " #"); commentIndex >= 0 {
+		value = strings.TrimSpace(value[:commentIndex])
+	}
+	return expand(value), nil
+}
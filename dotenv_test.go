@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DotEnvTestSuite struct {
+	suite.Suite
+	dataDirectory string
+}
+
+func TestDotEnvTestSuite(t *testing.T) {
+	suite.Run(t, new(DotEnvTestSuite))
+}
+
+func (s *DotEnvTestSuite) SetupTest() {
+	s.dataDirectory = path.Join(getCurrentWorkingDirectory(), "/data/test-env")
+}
+
+func (s *DotEnvTestSuite) Test_loadEnvFiles() {
+	t := s.T()
+	environment, err := loadEnvFiles([]string{
+		path.Join(s.dataDirectory, "/.env"),
+		path.Join(s.dataDirectory, "/override.env"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"APP_NAME=godev",
+		"APP_ENV=development",
+		"GREETING=hello from godev\nsecond line",
+		"LITERAL=${APP_NAME} is not expanded",
+		"URL=http://localhost:/path",
+		"APP_ENV=production",
+		"APP_TITLE=godev in production",
+	}, environment)
+}
+
+func (s *DotEnvTestSuite) Test_loadEnvFiles_nonExistent() {
+	_, err := loadEnvFiles([]string{path.Join(s.dataDirectory, "/does-not-exist.env")})
+	assert.NotNil(s.T(), err)
+}
+
+func (s *DotEnvTestSuite) Test_parseDotEnv_usesLookup() {
+	environment, err := parseDotEnv("PATH=$PATH:./bin", func(key string) (string, bool) {
+		return "/usr/bin", key == "PATH"
+	})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{"PATH=/usr/bin:./bin"}, environment)
+}
+
+func (s *DotEnvTestSuite) Test_parseDotEnv_withErrors() {
+	lookup := func(string) (string, bool) { return "", false }
+	_, err := parseDotEnv("# fine\nNOT A VARIABLE", lookup)
+	assert.Contains(s.T(), err.Error(), "line 2: expected KEY=VALUE")
+	_, err = parseDotEnv(`A="unterminated`, lookup)
+	assert.Contains(s.T(), err.Error(), "unterminated double-quoted value")
+	_, err = parseDotEnv(`A='unterminated`, lookup)
+	assert.Contains(s.T(), err.Error(), "unterminated single-quoted value")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	// EnvironmentInheritAll passes all of godev's environment to commands
+	EnvironmentInheritAll = "all"
+	// EnvironmentInheritNone starts commands with only the variables
+	// provided through --env and --env-file
+	EnvironmentInheritNone = "none"
+)
+
+const (
+	// EnvironmentBuildOutput holds the absolute path to the build output
+	EnvironmentBuildOutput = "GODEV_BUILD_OUTPUT"
+	// EnvironmentWorkDirectory holds the absolute path to the work directory
+	EnvironmentWorkDirectory = "GODEV_WORK_DIR"
+	// EnvironmentWatchDirectory holds the absolute path to the watched directory
+	EnvironmentWatchDirectory = "GODEV_WATCH_DIR"
+	// EnvironmentRunID holds the number of the pipeline run, it is only
+	// known when the run starts
+	EnvironmentRunID = "GODEV_RUN_ID"
+	// EnvironmentChangedFiles holds the newline-delimited paths of files
+	// which triggered the run
+	EnvironmentChangedFiles = "GODEV_CHANGED_FILES"
+	// EnvironmentChangesetFile holds the path to a file listing the
+	// files which triggered the run, one per line
+	EnvironmentChangesetFile = "GODEV_CHANGESET_FILE"
+)
+
+// parseEnvironmentInherit converts the --env-inherit value into a list
+// of glob patterns matching names of variables which should be inherited
+func parseEnvironmentInherit(inherit string) ([]string, error) {
+	switch strings.TrimSpace(inherit) {
+	case "", EnvironmentInheritAll:
+		return []string{"*"}, nil
+	case EnvironmentInheritNone:
+		return []string{}, nil
+	}
+	patterns := splitNonEmpty(inherit, ",")
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid --env-inherit pattern '%s': %s", pattern, err)
+		}
+	}
+	return patterns, nil
+}
+
+// composeEnvironment returns the environment a command runs with, where
+// :provided variables take precedence over variables in :inherited whose
+// names match :inherit. Duplicates are resolved with the last one winning
+func composeEnvironment(inherited []string, inherit string, provided []string) ([]string, error) {
+	patterns, err := parseEnvironmentInherit(inherit)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	values := map[string]string{}
+	for _, variable := range provided {
+		key, value := splitEnvironmentVariable(variable)
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	for _, variable := range inherited {
+		key, value := splitEnvironmentVariable(variable)
+		if _, ok := values[key]; ok || !matchesAnyPattern(patterns, key) {
+			continue
+		}
+		keys = append(keys, key)
+		values[key] = value
+	}
+	environment := []string{}
+	for _, key := range keys {
+		environment = append(environment, key+"="+values[key])
+	}
+	return environment, nil
+}
+
+// expandEnvironmentVariable expands $VAR and ${VAR} in the value of
+// :variable using :environment with the last definition winning, and
+// falls back to godev's own environment
+func expandEnvironmentVariable(variable string, environment []string) string {
+	key, value := splitEnvironmentVariable(variable)
+	if !strings.Contains(variable, "=") {
+		return variable
+	}
+	return key + "=" + os.Expand(value, func(name string) string {
+		for index := len(environment) - 1; index >= 0; index-- {
+			if existingKey, existingValue := splitEnvironmentVariable(environment[index]); existingKey == name {
+				return existingValue
+			}
+		}
+		return os.Getenv(name)
+	})
+}
+
+// splitEnvironmentVariable splits a KEY=VALUE pair
+func splitEnvironmentVariable(variable string) (string, string) {
+	keyValue := strings.SplitN(variable, "=", 2)
+	if len(keyValue) < 2 {
+		return keyValue[0], ""
+	}
+	return keyValue[0], keyValue[1]
+}
+
+// matchesAnyPattern checks whether :name matches any of the glob :patterns
+func matchesAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EnvironmentTestSuite struct {
+	suite.Suite
+	inherited []string
+}
+
+func TestEnvironmentTestSuite(t *testing.T) {
+	suite.Run(t, new(EnvironmentTestSuite))
+}
+
+func (s *EnvironmentTestSuite) SetupTest() {
+	s.inherited = []string{"PATH=/usr/bin", "HOME=/home/godev", "GOPATH=/go", "GOFLAGS=-mod=vendor", "SECRET=shh"}
+}
+
+func (s *EnvironmentTestSuite) Test_composeEnvironment_inheritAll() {
+	environment, err := composeEnvironment(s.inherited, EnvironmentInheritAll, []string{"SECRET=overridden", "A=1"})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{
+		"SECRET=overridden",
+		"A=1",
+		"PATH=/usr/bin",
+		"HOME=/home/godev",
+		"GOPATH=/go",
+		"GOFLAGS=-mod=vendor",
+	}, environment)
+}
+
+func (s *EnvironmentTestSuite) Test_composeEnvironment_inheritNone() {
+	environment, err := composeEnvironment(s.inherited, EnvironmentInheritNone, []string{"A=1", "A=2"})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{"A=2"}, environment)
+}
+
+func (s *EnvironmentTestSuite) Test_composeEnvironment_inheritAllowList() {
+	environment, err := composeEnvironment(s.inherited, "PATH,HOME,GO*", []string{"GOFLAGS=-mod=mod"})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{
+		"GOFLAGS=-mod=mod",
+		"PATH=/usr/bin",
+		"HOME=/home/godev",
+		"GOPATH=/go",
+	}, environment)
+}
+
+func (s *EnvironmentTestSuite) Test_composeEnvironment_invalidPattern() {
+	_, err := composeEnvironment(s.inherited, "GO[", nil)
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "invalid --env-inherit pattern 'GO['")
+}
+
+func (s *EnvironmentTestSuite) Test_expandEnvironmentVariable() {
+	t := s.T()
+	os.Setenv("GODEV_TEST_EXPAND", "from-godev")
+	defer os.Unsetenv("GODEV_TEST_EXPAND")
+	environment := []string{"BIN=/a/bin", "BIN=/b/bin"}
+	assert.Equal(t, "PATH=/b/bin:./bin", expandEnvironmentVariable("PATH=$BIN:./bin", environment))
+	assert.Equal(t, "VALUE=from-godev", expandEnvironmentVariable("VALUE=${GODEV_TEST_EXPAND}", environment))
+	assert.Equal(t, "NOT_A_VARIABLE", expandEnvironmentVariable("NOT_A_VARIABLE", environment))
+}
@@ -1,24 +1,83 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ExecutionGroupCount keeps track of the execution group count for
 // display in the verbose logs - helps to differentiate between
-// the different execution groups
-var ExecutionGroupCount = 0
+// the different execution groups, it's incremented atomically as
+// execution groups can start at the same time
+var ExecutionGroupCount int64
 
-// ExecutionGroup runs all commands in parallel
+// ExecutionGroup runs all commands in parallel, the Runner starts
+// it once the execution groups it depends on have succeeded which
+// is the previous execution group when dependsOn is nil. Groups with
+// a changeFilter are skipped if none of the files changed since they
+// last succeeded match it and groups with a cache are skipped if their inputs haven't changed.
+// Background groups are done once their readiness check passes and
+// keep running while the groups which depend on them run. Groups with
+// a maxParallel run at most that many commands at once and queue the
+// rest in the order they were specified
 type ExecutionGroup struct {
-	commands  []*Command
-	waitGroup sync.WaitGroup
-	logger    *Logger
+	name         string
+	dependsOn    []int
+	changeFilter *ChangeFilter
+	hasChanges   bool
+	cache        *ExecutionGroupCache
+	background   bool
+	readiness    *ReadinessCheck
+	maxParallel  int
+	commands     []*Command
+	pipelineRun  *PipelineRun
+	results      []*CommandResult
+	resultsMutex sync.Mutex
+	runs         sync.WaitGroup
+	activeRuns   int32
+	terminated   bool
+	queue        chan bool
+	queueMutex   sync.Mutex
+	waitGroup    sync.WaitGroup
+	logger       *Logger
+}
+
+// ErrExecutionGroupTerminated is returned by ExecutionGroup.Run when the
+// execution group was terminated before its commands completed
+var ErrExecutionGroupTerminated = errors.New("execution group was terminated")
+
+// GetName returns the name of the execution group, which defaults
+// to its position in the pipeline
+func (executionGroup *ExecutionGroup) GetName() string {
+	return executionGroup.name
+}
+
+// getCacheHash returns the hash of the execution group's cache inputs
+// and commands, or an empty string if the group isn't cached
+func (executionGroup *ExecutionGroup) getCacheHash() (string, error) {
+	if executionGroup.cache == nil {
+		return "", nil
+	}
+	var salt []string
+	for _, command := range executionGroup.commands {
+		salt = append(salt, command.config.Directory, command.config.Application)
+		salt = append(salt, command.config.Arguments...)
+		salt = append(salt, command.config.Environment...)
+	}
+	return executionGroup.cache.Hash(salt)
 }
 
 // IsRunning is for the Runner to check if the execution group
-// is still running
+// is still running, which is from when it's run until its run returns
+// even if its commands haven't started yet
 func (executionGroup *ExecutionGroup) IsRunning() bool {
+	if atomic.LoadInt32(&executionGroup.activeRuns) > 0 {
+		return true
+	}
 	for _, command := range executionGroup.commands {
 		if command.IsRunning() {
 			return true
@@ -27,40 +86,195 @@ func (executionGroup *ExecutionGroup) IsRunning() bool {
 	return false
 }
 
-// Run starts the execution group's commands in parallel
-// and waits for all of them to exit
-func (executionGroup *ExecutionGroup) Run() {
-	ExecutionGroupCount++
-	defer executionGroup.logger.Debugf("execution group[%v] exited", ExecutionGroupCount)
-	executionGroup.logger.Debugf("execution group[%v] is starting...", ExecutionGroupCount)
+// GetResults returns the results of the commands from the last run
+func (executionGroup *ExecutionGroup) GetResults() []*CommandResult {
+	executionGroup.resultsMutex.Lock()
+	defer executionGroup.resultsMutex.Unlock()
+	return append([]*CommandResult{}, executionGroup.results...)
+}
+
+// Run starts the execution group's commands in parallel and waits
+// for all of them to exit. Commands over the maxParallel are queued and
+// started in order as the running ones exit. An error is returned if
+// the group was terminated or if a command failed without continue_on_error
+func (executionGroup *ExecutionGroup) Run() error {
+	executionGroup.runs.Add(1)
+	defer executionGroup.runs.Done()
+	atomic.AddInt32(&executionGroup.activeRuns, 1)
+	defer atomic.AddInt32(&executionGroup.activeRuns, -1)
+	count := atomic.AddInt64(&ExecutionGroupCount, 1)
+	defer executionGroup.logger.Debugf("execution group[%v] exited", count)
+	executionGroup.logger.Debugf("execution group[%v] is starting...", count)
+	executionGroup.results = nil
+	queue := executionGroup.openQueue()
+	var commands []*Command
 	for _, command := range executionGroup.commands {
 		if err := command.IsValid(); err != nil {
 			executionGroup.logger.Error(err)
+			executionGroup.addResult(newCommandResult(command, err, false))
 		} else {
-			go func(commandStatus *chan error) {
-				for {
-					select {
-					case err := <-*commandStatus: // Command letting us know its done
-						executionGroup.handleCommandStatus(command, err)
-						return
-					default:
-					}
+			commands = append(commands, command)
+		}
+	}
+	slots := executionGroup.getSlots(commands)
+	for index, command := range commands {
+		if !executionGroup.waitForSlot(slots, queue) {
+			for _, queuedCommand := range commands[index:] {
+				executionGroup.logger.Debugf("command[%s] was not started, the execution group was terminated", queuedCommand.GetID())
+				executionGroup.addResult(newCommandResult(queuedCommand, ErrExecutionGroupTerminated, true))
+			}
+			break
+		}
+		go func(command *Command, commandStatus *chan error) {
+			for {
+				select {
+				case err := <-*commandStatus: // Command letting us know its done
+					executionGroup.handleCommandStatus(command, err, slots)
+					return
+				default:
 				}
-			}(command.GetStatus())
+			}
+		}(command, command.GetStatus())
+		if slots != nil && index >= cap(slots) {
+			executionGroup.logger.Infof("command[%s] is starting from the queue, %v command(s) still queued", command.GetID(), len(commands)-index-1)
+		} else {
 			executionGroup.logger.Tracef("command[%s] is starting", command.GetID())
-			executionGroup.waitGroup.Add(1)
-			go command.Run()
 		}
+		command.pipelineRun = executionGroup.pipelineRun
+		executionGroup.waitGroup.Add(1)
+		go command.Run()
 	}
 	executionGroup.logger.Tracef("waiting for commands to complete running...")
 	executionGroup.waitGroup.Wait()
+	if executionGroup.isTerminated() {
+		return ErrExecutionGroupTerminated
+	}
+	for _, result := range executionGroup.GetResults() {
+		if result.IsFailure() {
+			return fmt.Errorf("%s", result)
+		}
+	}
+	return nil
+}
+
+// getSlots returns the channel which limits how many of :commands run
+// at once and logs the ones which are queued, it is nil when all of
+// them can run at once
+func (executionGroup *ExecutionGroup) getSlots(commands []*Command) chan bool {
+	if executionGroup.maxParallel <= 0 || len(commands) <= executionGroup.maxParallel {
+		return nil
+	}
+	var queued []string
+	for _, command := range commands[executionGroup.maxParallel:] {
+		queued = append(queued, command.GetID())
+	}
+	executionGroup.logger.Infof(
+		"execution group[%s] runs %v of its %v commands at a time, queued in order: %s",
+		executionGroup.GetName(), executionGroup.maxParallel, len(commands), strings.Join(queued, ", "),
+	)
+	return make(chan bool, executionGroup.maxParallel)
+}
+
+// waitForSlot blocks until one of the :slots is free and takes it,
+// it returns false if the :queue was closed by Terminate first
+func (executionGroup *ExecutionGroup) waitForSlot(slots chan bool, queue chan bool) bool {
+	select {
+	case <-queue:
+		return false
+	default:
+	}
+	if slots == nil {
+		return true
+	}
+	select {
+	case slots <- true:
+		return true
+	case <-queue:
+		return false
+	}
+}
+
+// openQueue creates the channel which Terminate closes to stop queued
+// commands from being started
+func (executionGroup *ExecutionGroup) openQueue() chan bool {
+	executionGroup.queueMutex.Lock()
+	defer executionGroup.queueMutex.Unlock()
+	executionGroup.terminated = false
+	executionGroup.queue = make(chan bool)
+	return executionGroup.queue
+}
+
+// closeQueue marks the current run as terminated and stops its queued
+// commands from being started, it is safe to call more than once
+func (executionGroup *ExecutionGroup) closeQueue() {
+	executionGroup.queueMutex.Lock()
+	defer executionGroup.queueMutex.Unlock()
+	executionGroup.terminated = true
+	if executionGroup.queue != nil {
+		close(executionGroup.queue)
+		executionGroup.queue = nil
+	}
+}
+
+// isTerminated checks whether the current run was terminated
+func (executionGroup *ExecutionGroup) isTerminated() bool {
+	executionGroup.queueMutex.Lock()
+	defer executionGroup.queueMutex.Unlock()
+	return executionGroup.terminated
+}
+
+// RunUntilReady starts the execution group and returns once it's
+// ready, leaving it running in the background. The execution group is
+// terminated and an error is returned if it isn't ready before its
+// readiness check times out, an error is also returned if it exits
+// before it's ready
+func (executionGroup *ExecutionGroup) RunUntilReady() error {
+	if executionGroup.readiness != nil {
+		executionGroup.readiness.Reset()
+		if executionGroup.readiness.Log != nil {
+			for _, command := range executionGroup.commands {
+				command.output = executionGroup.readiness
+			}
+		}
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- executionGroup.Run()
+	}()
+	ready := make(chan error, 1)
+	stop := make(chan bool)
+	go func() {
+		ready <- executionGroup.readiness.Wait(stop)
+	}()
+	select {
+	case err := <-exited:
+		close(stop)
+		if err == nil {
+			err = ErrExecutionGroupExitedBeforeReady
+		}
+		return err
+	case err := <-ready:
+		if err != nil {
+			executionGroup.Terminate()
+			<-exited
+			return err
+		}
+	}
+	executionGroup.logger.Debugf("execution group[%s] is ready", executionGroup.GetName())
+	go func() {
+		if err := <-exited; err != nil && err != ErrExecutionGroupTerminated {
+			executionGroup.logger.Warnf("execution group[%s] exited while running in the background: %s", executionGroup.GetName(), err)
+		}
+	}()
+	return nil
 }
 
 // Terminate terminates this instance of the execution group, used when
 // the Runner receives a signal to start a new pipeline
 func (executionGroup *ExecutionGroup) Terminate() {
+	executionGroup.closeQueue()
 	for _, command := range executionGroup.commands {
-		if command.IsRunning() {
+		if command.IsRunning() && !command.isInterrupted() {
 			executionGroup.logger.Tracef("sending SIGINT to command %v", command.GetID())
 			command.SendInterrupt()
 			executionGroup.logger.Tracef("SIGINT sent to command %v", command.GetID())
@@ -68,7 +282,34 @@ func (executionGroup *ExecutionGroup) Terminate() {
 	}
 }
 
-func (executionGroup *ExecutionGroup) handleCommandStatus(command *Command, err error) {
+// Stop terminates the execution group and blocks until it has stopped
+// running. It's terminated again every CommandStopPollInterval so that
+// commands which were still starting when it was first terminated are
+// interrupted once they have started
+func (executionGroup *ExecutionGroup) Stop() {
+	stopped := make(chan bool)
+	go func() {
+		executionGroup.Wait()
+		close(stopped)
+	}()
+	executionGroup.Terminate()
+	for {
+		select {
+		case <-stopped:
+			return
+		case <-time.After(CommandStopPollInterval):
+			executionGroup.Terminate()
+		}
+	}
+}
+
+// Wait blocks until the execution group has stopped running, used
+// to make sure it has exited after being terminated
+func (executionGroup *ExecutionGroup) Wait() {
+	executionGroup.runs.Wait()
+}
+
+func (executionGroup *ExecutionGroup) handleCommandStatus(command *Command, err error, slots chan bool) {
 	defer func() {
 		if r := recover(); r != nil {
 			executionGroup.logger.Warn(r)
@@ -79,5 +320,15 @@ func (executionGroup *ExecutionGroup) handleCommandStatus(command *Command, err
 	} else {
 		executionGroup.logger.Debugf("command[%s] exited without error", command.GetID())
 	}
+	executionGroup.addResult(newCommandResult(command, err, executionGroup.isTerminated()))
+	if slots != nil {
+		<-slots
+	}
 	executionGroup.waitGroup.Done()
 }
+
+func (executionGroup *ExecutionGroup) addResult(result *CommandResult) {
+	executionGroup.resultsMutex.Lock()
+	defer executionGroup.resultsMutex.Unlock()
+	executionGroup.results = append(executionGroup.results, result)
+}
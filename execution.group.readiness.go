@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReadinessTimeout is how long a background execution group has
+// to become ready before it is terminated
+const DefaultReadinessTimeout = 30 * time.Second
+
+// ReadinessCheckInterval is the interval between readiness checks
+const ReadinessCheckInterval = 250 * time.Millisecond
+
+// ReadinessCheck decides when a background execution group is ready,
+// which is when all of the checks it specifies pass: TCP is an address
+// accepting connections, HTTP is a URL responding with a 2xx status,
+// Log is a pattern matching a line of the output of the group's
+// commands and File is the path to a file which exists
+type ReadinessCheck struct {
+	TCP        string
+	HTTP       string
+	Log        *regexp.Regexp
+	File       string
+	Timeout    time.Duration
+	logLine    []byte
+	logMatched bool
+	mutex      sync.Mutex
+}
+
+// Reset forgets the output seen by the check so far, used before the
+// execution group is started again
+func (check *ReadinessCheck) Reset() {
+	check.mutex.Lock()
+	defer check.mutex.Unlock()
+	check.logLine = nil
+	check.logMatched = false
+}
+
+// Write looks for a line of :output which matches the Log pattern so
+// that the check can be used as the output of commands
+func (check *ReadinessCheck) Write(output []byte) (int, error) {
+	check.mutex.Lock()
+	defer check.mutex.Unlock()
+	if check.logMatched || check.Log == nil {
+		return len(output), nil
+	}
+	check.logLine = append(check.logLine, output...)
+	for {
+		end := bytes.IndexByte(check.logLine, '\n')
+		if end < 0 {
+			break
+		}
+		if check.Log.Match(bytes.TrimRight(check.logLine[:end], "\r")) {
+			check.logMatched = true
+			check.logLine = nil
+			break
+		}
+		check.logLine = check.logLine[end+1:]
+	}
+	return len(output), nil
+}
+
+// Wait checks for readiness every ReadinessCheckInterval until all of
+// the checks pass, an error is returned if they haven't passed before
+// the Timeout. It returns nil straight away if :check is nil and stops
+// checking when :stop is closed
+func (check *ReadinessCheck) Wait(stop <-chan bool) error {
+	if check == nil {
+		return nil
+	}
+	timeout := check.Timeout
+	if timeout == 0 {
+		timeout = DefaultReadinessTimeout
+	}
+	deadline := time.After(timeout)
+	for {
+		err := check.check()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-stop:
+			return err
+		case <-deadline:
+			return fmt.Errorf("not ready after %s: %s", timeout, err)
+		case <-time.After(ReadinessCheckInterval):
+		}
+	}
+}
+
+// check returns the reason for the first check which didn't pass
+func (check *ReadinessCheck) check() error {
+	if len(check.TCP) > 0 {
+		connection, err := net.DialTimeout("tcp", check.TCP, ReadinessCheckInterval)
+		if err != nil {
+			return fmt.Errorf("'%s' is not accepting connections", check.TCP)
+		}
+		connection.Close()
+	}
+	if len(check.HTTP) > 0 {
+		client := &http.Client{Timeout: ReadinessCheckInterval}
+		response, err := client.Get(check.HTTP)
+		if err != nil {
+			return fmt.Errorf("'%s' is not responding", check.HTTP)
+		}
+		response.Body.Close()
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			return fmt.Errorf("'%s' responded with status %v", check.HTTP, response.StatusCode)
+		}
+	}
+	if check.Log != nil {
+		check.mutex.Lock()
+		logMatched := check.logMatched
+		check.mutex.Unlock()
+		if !logMatched {
+			return fmt.Errorf("no output matched '%s'", check.Log)
+		}
+	}
+	if len(check.File) > 0 {
+		if _, err := os.Stat(check.File); err != nil {
+			return fmt.Errorf("'%s' does not exist", check.File)
+		}
+	}
+	return nil
+}
+
+// String returns the checks for display
+func (check *ReadinessCheck) String() string {
+	var checks []string
+	if len(check.TCP) > 0 {
+		checks = append(checks, "tcp "+check.TCP)
+	}
+	if len(check.HTTP) > 0 {
+		checks = append(checks, "http "+check.HTTP)
+	}
+	if check.Log != nil {
+		checks = append(checks, "log "+check.Log.String())
+	}
+	if len(check.File) > 0 {
+		checks = append(checks, "file "+check.File)
+	}
+	return strings.Join(checks, ", ")
+}
+
+// ErrExecutionGroupExitedBeforeReady is returned by ExecutionGroup.RunUntilReady
+// when the execution group exited without an error before it was ready
+var ErrExecutionGroupExitedBeforeReady = errors.New("exited before it was ready")
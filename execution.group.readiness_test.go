@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReadinessCheckTestSuite struct {
+	suite.Suite
+}
+
+func TestReadinessCheckTestSuite(t *testing.T) {
+	suite.Run(t, new(ReadinessCheckTestSuite))
+}
+
+func (s *ReadinessCheckTestSuite) TestWrite() {
+	t := s.T()
+	check := &ReadinessCheck{Log: regexp.MustCompile(`^listening on :\d+$`)}
+	check.Write([]byte("starting\nlisten"))
+	assert.NotNil(t, check.check())
+	check.Write([]byte("ing on :8080\r\nserving"))
+	assert.Nil(t, check.check())
+	check.Reset()
+	assert.NotNil(t, check.check())
+}
+
+func (s *ReadinessCheckTestSuite) TestWait_withNilCheck() {
+	var check *ReadinessCheck
+	assert.Nil(s.T(), check.Wait(nil))
+}
+
+func (s *ReadinessCheckTestSuite) TestWait_withTCP() {
+	t := s.T()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	check := &ReadinessCheck{TCP: listener.Addr().String(), Timeout: time.Second}
+	assert.Nil(t, check.Wait(nil))
+	listener.Close()
+	err = check.Wait(nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "not ready after 1s")
+	assert.Contains(t, err.Error(), "is not accepting connections")
+}
+
+func (s *ReadinessCheckTestSuite) TestWait_withHTTP() {
+	t := s.T()
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(status)
+	}))
+	defer server.Close()
+	check := &ReadinessCheck{HTTP: server.URL, Timeout: 10 * time.Second}
+	go func() {
+		time.Sleep(3 * ReadinessCheckInterval)
+		status = http.StatusNoContent
+	}()
+	started := time.Now()
+	assert.Nil(t, check.Wait(nil))
+	assert.True(t, time.Since(started) >= 3*ReadinessCheckInterval)
+	status = http.StatusInternalServerError
+	check.Timeout = ReadinessCheckInterval
+	err := check.Wait(nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "responded with status 500")
+}
+
+func (s *ReadinessCheckTestSuite) TestWait_withFile() {
+	t := s.T()
+	directory, err := ioutil.TempDir("", "godev-readiness-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	check := &ReadinessCheck{File: path.Join(directory, "ready"), Timeout: time.Minute}
+	stop := make(chan bool)
+	close(stop)
+	err = check.Wait(stop)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+	assert.Nil(t, ioutil.WriteFile(check.File, []byte{}, 0644))
+	assert.Nil(t, check.Wait(nil))
+}
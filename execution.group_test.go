@@ -1,10 +1,14 @@
 package main
 
 import (
-	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
 	"regexp"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -13,7 +17,7 @@ import (
 type ExecutionGroupTestSuite struct {
 	suite.Suite
 	executionGroup *ExecutionGroup
-	logs           bytes.Buffer
+	logs           lockedBuffer
 	logger         *Logger
 }
 
@@ -83,7 +87,127 @@ func (s *ExecutionGroupTestSuite) Test_handleCommandStatus() {
 	t := s.T()
 	testCommand := mockCommand("echo", []string{"1"}, &s.logs)
 	s.executionGroup.waitGroup.Add(1)
-	s.executionGroup.handleCommandStatus(testCommand, nil)
+	s.executionGroup.handleCommandStatus(testCommand, nil, nil)
 	s.executionGroup.waitGroup.Wait()
 	assert.Contains(t, s.logs.String(), "command[echo[1]] exited without error")
 }
+
+func (s *ExecutionGroupTestSuite) TestRun_withFailingCommand() {
+	t := s.T()
+	s.executionGroup.commands = []*Command{
+		mockCommand("echo", []string{"1"}, &s.logs),
+		mockCommand("false", []string{}, &s.logs),
+	}
+	err := s.executionGroup.Run()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "command[false[]] 'false' failed with exit code 1")
+	assert.Len(t, s.executionGroup.GetResults(), 2)
+}
+
+func (s *ExecutionGroupTestSuite) TestRun_withFailingCommandContinuingOnError() {
+	t := s.T()
+	command := mockCommand("false", []string{}, &s.logs)
+	command.config.ContinueOnError = true
+	s.executionGroup.commands = []*Command{command}
+	assert.Nil(t, s.executionGroup.Run())
+	assert.Equal(t, 1, s.executionGroup.GetResults()[0].ExitCode)
+}
+
+func (s *ExecutionGroupTestSuite) TestRun_withInvalidCommand() {
+	t := s.T()
+	s.executionGroup.commands = []*Command{
+		mockCommand("/does/and/should/not/exist", []string{}, &s.logs),
+	}
+	err := s.executionGroup.Run()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "could not be found")
+}
+
+func (s *ExecutionGroupTestSuite) TestRun_withMaxParallel() {
+	t := s.T()
+	output := path.Join(os.TempDir(), fmt.Sprintf("godev-max-parallel-%v", time.Now().UnixNano()))
+	defer os.Remove(output)
+	s.executionGroup.name = "test"
+	s.executionGroup.maxParallel = 1
+	for _, name := range []string{"a", "b", "c"} {
+		script := fmt.Sprintf("echo start %s >> %s; sleep 0.1; echo end %s >> %s", name, output, name, output)
+		s.executionGroup.commands = append(s.executionGroup.commands, mockCommand("sh", []string{"-c", script}, &s.logs))
+	}
+	assert.Nil(t, s.executionGroup.Run())
+	contents, err := ioutil.ReadFile(output)
+	assert.Nil(t, err)
+	assert.Equal(t, "start a\nend a\nstart b\nend b\nstart c\nend c\n", string(contents))
+	assert.Contains(t, s.logs.String(), fmt.Sprintf(
+		"execution group[test] runs 1 of its 3 commands at a time, queued in order: %s, %s",
+		s.executionGroup.commands[1].GetID(), s.executionGroup.commands[2].GetID(),
+	))
+	assert.Contains(t, s.logs.String(), fmt.Sprintf("command[%s] is starting from the queue, 1 command(s) still queued", s.executionGroup.commands[1].GetID()))
+	assert.Contains(t, s.logs.String(), fmt.Sprintf("command[%s] is starting from the queue, 0 command(s) still queued", s.executionGroup.commands[2].GetID()))
+	assert.Len(t, s.executionGroup.GetResults(), 3)
+}
+
+func (s *ExecutionGroupTestSuite) TestRun_withMaxParallelTerminated() {
+	t := s.T()
+	s.executionGroup.maxParallel = 1
+	s.executionGroup.commands = []*Command{
+		mockCommand("sleep", []string{"10"}, &s.logs),
+		mockCommand("sleep", []string{"11"}, &s.logs),
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- s.executionGroup.Run()
+	}()
+	for !s.executionGroup.IsRunning() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.executionGroup.Stop()
+	select {
+	case err := <-exited:
+		assert.Equal(t, ErrExecutionGroupTerminated, err)
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "execution group did not exit after being terminated")
+	}
+	results := s.executionGroup.GetResults()
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.True(t, result.Terminated)
+	}
+	assert.Contains(t, s.logs.String(), "command[sleep[11]] was not started, the execution group was terminated")
+}
+
+func (s *ExecutionGroupTestSuite) TestRunUntilReady() {
+	t := s.T()
+	s.executionGroup.background = true
+	s.executionGroup.readiness = &ReadinessCheck{Log: regexp.MustCompile("^ready$"), Timeout: 10 * time.Second}
+	s.executionGroup.commands = []*Command{
+		mockCommand("sh", []string{"-c", "echo starting; sleep 0.2; echo ready; sleep 10"}, &s.logs),
+	}
+	assert.Nil(t, s.executionGroup.RunUntilReady())
+	assert.True(t, s.executionGroup.IsRunning())
+	s.executionGroup.Terminate()
+	s.executionGroup.Wait()
+	assert.False(t, s.executionGroup.IsRunning())
+}
+
+func (s *ExecutionGroupTestSuite) TestRunUntilReady_withTimeout() {
+	t := s.T()
+	s.executionGroup.readiness = &ReadinessCheck{Log: regexp.MustCompile("^ready$"), Timeout: 500 * time.Millisecond}
+	s.executionGroup.commands = []*Command{
+		mockCommand("sleep", []string{"10"}, &s.logs),
+	}
+	started := time.Now()
+	err := s.executionGroup.RunUntilReady()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "not ready after 500ms: no output matched '^ready$'")
+	assert.True(t, time.Since(started) < 5*time.Second)
+	assert.False(t, s.executionGroup.IsRunning())
+}
+
+func (s *ExecutionGroupTestSuite) TestRunUntilReady_withEarlyExit() {
+	t := s.T()
+	s.executionGroup.readiness = &ReadinessCheck{TCP: "127.0.0.1:1", Timeout: 10 * time.Second}
+	s.executionGroup.commands = []*Command{
+		mockCommand("true", []string{}, &s.logs),
+	}
+	assert.Equal(t, ErrExecutionGroupExitedBeforeReady, s.executionGroup.RunUntilReady())
+}
@@ -31,6 +31,24 @@ func getFlagCommandsDelimiter() cli.Flag {
 	}
 }
 
+// getFlagCrashLoopExits provisions --crash-loop-exits
+func getFlagCrashLoopExits() cli.Flag {
+	return cli.IntFlag{
+		Name:  "crash-loop-exits",
+		Usage: "| where <value> is the number of exits within the --crash-loop-window after which the final execution group stops being restarted",
+		Value: DefaultCrashLoopExits,
+	}
+}
+
+// getFlagCrashLoopWindow provisions --crash-loop-window
+func getFlagCrashLoopWindow() cli.Flag {
+	return cli.DurationFlag{
+		Name:  "crash-loop-window",
+		Usage: "| where <value> is a duration",
+		Value: DefaultCrashLoopWindow,
+	}
+}
+
 // getFlagEnvVars provisions --env
 func getFlagEnvVars() cli.Flag {
 	return cli.StringSliceFlag{
@@ -39,6 +57,23 @@ func getFlagEnvVars() cli.Flag {
 	}
 }
 
+// getFlagEnvInherit provisions --env-inherit
+func getFlagEnvInherit() cli.Flag {
+	return cli.StringFlag{
+		Name:  "env-inherit",
+		Usage: "| where <value> is 'all', 'none' or a comma-delimited set of variable names (globs allowed) from godev's environment to pass to commands",
+		Value: DefaultEnvInherit,
+	}
+}
+
+// getFlagEnvFiles provisions --env-file
+func getFlagEnvFiles() cli.Flag {
+	return cli.StringSliceFlag{
+		Name:  "env-file",
+		Usage: "| where <value> is the path to a dotenv file relative to the work directory - specify multiple of these to load multiple files",
+	}
+}
+
 // getFlagExecGroups provisions --exec
 func getFlagExecGroups() cli.Flag {
 	return cli.StringSliceFlag{
@@ -56,6 +91,23 @@ func getFlagFileExtensions() cli.Flag {
 	}
 }
 
+// getFlagFollow provisions --follow
+func getFlagFollow() cli.Flag {
+	return cli.BoolFlag{
+		Name:  "follow, f",
+		Usage: "| keep printing output as it is written to the log files, switching to new pipeline runs unless a run id is specified",
+	}
+}
+
+// getFlagFormat provisions --format
+func getFlagFormat() cli.Flag {
+	return cli.StringFlag{
+		Name:  "format, f",
+		Usage: "| where <value> is one of 'yaml' or 'json'",
+		Value: "yaml",
+	}
+}
+
 // getFlagIgnoredNames provisions --ignore
 func getFlagIgnoredNames() cli.Flag {
 	return cli.StringFlag{
@@ -65,6 +117,58 @@ func getFlagIgnoredNames() cli.Flag {
 	}
 }
 
+// getFlagLogsKeepRuns provisions --logs-keep-runs
+func getFlagLogsKeepRuns() cli.Flag {
+	return cli.IntFlag{
+		Name:  "logs-keep-runs",
+		Usage: "| where <value> is the number of pipeline runs whose command output is kept in log files - 0 disables the log files",
+		Value: DefaultLogsKeepRuns,
+	}
+}
+
+// getFlagLogsMaxSize provisions --logs-max-size
+func getFlagLogsMaxSize() cli.Flag {
+	return cli.StringFlag{
+		Name:  "logs-max-size",
+		Usage: "| where <value> is the size the log files of pipeline runs are kept under (eg. 512KB, 100MB, 1GB) - 0 for no limit",
+		Value: DefaultLogsMaxSize,
+	}
+}
+
+// getFlagMaxParallel provisions --max-parallel
+func getFlagMaxParallel() cli.Flag {
+	return cli.IntFlag{
+		Name:  "max-parallel",
+		Usage: "| where <value> is the number of commands an execution group runs at once, the rest are queued - 0 runs all of them at once",
+		Value: DefaultMaxParallel,
+	}
+}
+
+// getFlagOutputMode provisions --output-mode
+func getFlagOutputMode() cli.Flag {
+	return cli.StringFlag{
+		Name:  "output-mode",
+		Usage: "| where <value> is one of 'raw' to write the output of commands as-is, 'prefixed' to prefix each line with the name of its command or 'grouped' to write the output of each command as one block once it exits",
+		Value: DefaultOutputMode,
+	}
+}
+
+// getFlagProfile provisions --profile
+func getFlagProfile() cli.Flag {
+	return cli.StringFlag{
+		Name:  "profile, p",
+		Usage: "| where <value> is the name of a profile defined in the config file",
+	}
+}
+
+// getFlagPTY provisions --pty
+func getFlagPTY() cli.Flag {
+	return cli.BoolFlag{
+		Name:  "pty",
+		Usage: "| run commands attached to a pseudo-terminal so that they write their output as they would to a terminal",
+	}
+}
+
 // getFlagRate provisions --rate
 func getFlagRate() cli.Flag {
 	return cli.DurationFlag{
@@ -74,23 +178,80 @@ func getFlagRate() cli.Flag {
 	}
 }
 
+// getFlagRestartAlways provisions --restart-always
+func getFlagRestartAlways() cli.Flag {
+	return cli.BoolFlag{
+		Name:  "restart-always",
+		Usage: "| restart the final execution group even when the build output is identical to the one running",
+	}
+}
+
+// getFlagRestartPolicy provisions --restart-policy
+func getFlagRestartPolicy() cli.Flag {
+	return cli.StringFlag{
+		Name:  "restart-policy",
+		Usage: "| where <value> is one of 'never', 'on-failure' or 'always' - when to restart the final execution group after it exits on its own",
+		Value: DefaultRestartPolicy,
+	}
+}
+
+// getFlagShell provisions --shell
+func getFlagShell() cli.Flag {
+	return cli.StringFlag{
+		Name:  "shell",
+		Usage: "| where <value> is the path to a shell (eg. /bin/sh) to run every command through - prefix a command with 'sh:' to run only that command through a shell",
+		Value: DefaultShell,
+	}
+}
+
+// getFlagStdin provisions --stdin
+func getFlagStdin() cli.Flag {
+	return cli.BoolFlag{
+		Name:  "stdin",
+		Usage: "| forward godev's stdin to the first command of the final execution group",
+	}
+}
+
+// getFlagStopSignals provisions --stop-signals
+func getFlagStopSignals() cli.Flag {
+	return cli.StringFlag{
+		Name:  "stop-signals",
+		Usage: "| where <value> is a comma-delimited list of signals sent to the process groups of commands being stopped, each optionally followed by the delay after which it is sent (eg. SIGINT,SIGKILL:5s)",
+		Value: DefaultStopSignals,
+	}
+}
+
+// getFlagSwap provisions --swap
+func getFlagSwap() cli.Flag {
+	return cli.BoolFlag{
+		Name:  "swap",
+		Usage: "| keep the application in the final execution group running until the execution groups before it have built a replacement",
+	}
+}
+
 // etFlagWatchDirectory provisions --watch
 func getFlagWatchDirectory() cli.Flag {
 	return cli.StringFlag{
-		EnvVar: "watch",
-		Name:   "watch",
-		Usage:  "| where <value> is an absolute path to a directory to watch",
-		Value:  getCurrentWorkingDirectory(),
+		Name:  "watch",
+		Usage: "| where <value> is an absolute path to a directory to watch",
+		Value: getCurrentWorkingDirectory(),
 	}
 }
 
 // getFlagWorkDirectory provisions --dir
 func getFlagWorkDirectory() cli.Flag {
 	return cli.StringFlag{
-		EnvVar: "DIR",
-		Name:   "dir",
-		Usage:  "| where <value> is an absolute path to a directory to use as the current working directory",
-		Value:  getCurrentWorkingDirectory(),
+		Name:  "dir",
+		Usage: "| where <value> is an absolute path to a directory to use as the current working directory",
+		Value: getCurrentWorkingDirectory(),
+	}
+}
+
+// getFlagTestMode provisions --test
+func getFlagTestMode() cli.Flag {
+	return cli.BoolFlag{
+		Name:  "test",
+		Usage: "| resolve the configuration for the test sub-command",
 	}
 }
 
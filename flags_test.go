@@ -27,10 +27,26 @@ func (s *FlagsTestSuite) Test_getFlagCommandsDelimiter() {
 	ensureFlag(s.T(), getFlagCommandsDelimiter(), cli.StringFlag{}, `^exec-delim.*`)
 }
 
+func (s *FlagsTestSuite) Test_getFlagCrashLoopExits() {
+	ensureFlag(s.T(), getFlagCrashLoopExits(), cli.IntFlag{}, `^crash-loop-exits$`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagCrashLoopWindow() {
+	ensureFlag(s.T(), getFlagCrashLoopWindow(), cli.DurationFlag{}, `^crash-loop-window$`)
+}
+
 func (s *FlagsTestSuite) Test_getFlagEnvVars() {
 	ensureFlag(s.T(), getFlagEnvVars(), cli.StringSliceFlag{}, `^env.*`)
 }
 
+func (s *FlagsTestSuite) Test_getFlagEnvFiles() {
+	ensureFlag(s.T(), getFlagEnvFiles(), cli.StringSliceFlag{}, `^env-file$`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagEnvInherit() {
+	ensureFlag(s.T(), getFlagEnvInherit(), cli.StringFlag{}, `^env-inherit$`)
+}
+
 func (s *FlagsTestSuite) Test_getFlagExecGroups() {
 	ensureFlag(s.T(), getFlagExecGroups(), cli.StringSliceFlag{}, `^exec.*`)
 }
@@ -39,14 +55,58 @@ func (s *FlagsTestSuite) Test_getFlagFileExtensions() {
 	ensureFlag(s.T(), getFlagFileExtensions(), cli.StringFlag{}, `^exts.*`)
 }
 
+func (s *FlagsTestSuite) Test_getFlagFormat() {
+	ensureFlag(s.T(), getFlagFormat(), cli.StringFlag{}, `^format.*`)
+}
+
 func (s *FlagsTestSuite) Test_getFlagIgnoredNames() {
 	ensureFlag(s.T(), getFlagIgnoredNames(), cli.StringFlag{}, `^ignore.*`)
 }
 
+func (s *FlagsTestSuite) Test_getFlagFollow() {
+	ensureFlag(s.T(), getFlagFollow(), cli.BoolFlag{}, `^follow.*`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagLogsKeepRuns() {
+	ensureFlag(s.T(), getFlagLogsKeepRuns(), cli.IntFlag{}, `^logs-keep-runs$`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagLogsMaxSize() {
+	ensureFlag(s.T(), getFlagLogsMaxSize(), cli.StringFlag{}, `^logs-max-size$`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagMaxParallel() {
+	ensureFlag(s.T(), getFlagMaxParallel(), cli.IntFlag{}, `^max-parallel$`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagOutputMode() {
+	ensureFlag(s.T(), getFlagOutputMode(), cli.StringFlag{}, `^output-mode$`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagProfile() {
+	ensureFlag(s.T(), getFlagProfile(), cli.StringFlag{}, `^profile.*`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagStdin() {
+	ensureFlag(s.T(), getFlagStdin(), cli.BoolFlag{}, `^stdin$`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagPTY() {
+	ensureFlag(s.T(), getFlagPTY(), cli.BoolFlag{}, `^pty$`)
+}
+
 func (s *FlagsTestSuite) Test_getFlagRate() {
 	ensureFlag(s.T(), getFlagRate(), cli.DurationFlag{}, `^rate.*`)
 }
 
+func (s *FlagsTestSuite) Test_getFlagRestartPolicy() {
+	ensureFlag(s.T(), getFlagRestartPolicy(), cli.StringFlag{}, `^restart-policy$`)
+}
+
+func (s *FlagsTestSuite) Test_getFlagShell() {
+	ensureFlag(s.T(), getFlagShell(), cli.StringFlag{}, `^shell$`)
+}
+
 func (s *FlagsTestSuite) Test_getFlagWatchDirectory() {
 	ensureFlag(s.T(), getFlagWatchDirectory(), cli.StringFlag{}, `^watch.*`)
 }
@@ -55,6 +115,10 @@ func (s *FlagsTestSuite) Test_getFlagWorkDirectory() {
 	ensureFlag(s.T(), getFlagWorkDirectory(), cli.StringFlag{}, `^dir.*`)
 }
 
+func (s *FlagsTestSuite) Test_getFlagTestMode() {
+	ensureFlag(s.T(), getFlagTestMode(), cli.BoolFlag{}, `^test$`)
+}
+
 func (s *FlagsTestSuite) Test_getFlagCommit() {
 	ensureFlag(s.T(), getFlagCommit(), cli.BoolFlag{}, `^commit.*`)
 }
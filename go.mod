@@ -1,10 +1,13 @@
 module github.com/zephinzer/godev
 
 require (
+	github.com/BurntSushi/toml v0.3.1
+	github.com/creack/pty v1.1.24
 	github.com/fsnotify/fsnotify v1.4.7
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
 	github.com/sirupsen/logrus v1.3.0
 	github.com/stretchr/testify v1.3.0
 	github.com/urfave/cli v1.20.0
 	golang.org/x/sys v0.0.0-20190222171317-cd391775e71e // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bytes"
+	"io"
 
 	"github.com/sirupsen/logrus"
 )
@@ -43,8 +43,8 @@ type Logger struct {
 }
 
 // SetOutput exists for characterisation testing
-func (l *Logger) SetOutput(buffer *bytes.Buffer) {
-	l.instanceRaw.SetOutput(buffer)
+func (l *Logger) SetOutput(output io.Writer) {
+	l.instanceRaw.SetOutput(output)
 }
 
 // Trace logs at the trace level
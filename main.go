@@ -5,11 +5,10 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"os/signal"
 	"path"
 	"strings"
 	"sync"
-
-	shellquote "github.com/kballard/go-shellquote"
 )
 
 func main() {
@@ -17,6 +16,11 @@ func main() {
 	app.Start(os.Args, func(config *Config) {
 		godev := InitGoDev(config)
 		godev.Start()
+		// exiting on success would hide the errors of sub-commands
+		// which the cli reports once this returns
+		if godev.exitCode != 0 {
+			os.Exit(godev.exitCode)
+		}
 	})
 }
 
@@ -35,15 +39,21 @@ func InitGoDev(config *Config) *GoDev {
 
 // GoDev holds the logic and values needed for GoDev to run
 type GoDev struct {
-	config  *Config
-	logger  *Logger
-	watcher *Watcher
-	runner  *Runner
+	config   *Config
+	exitCode int
+	logger   *Logger
+	stdin    *StdinForwarder
+	watcher  *Watcher
+	runner   *Runner
 }
 
 // Start should only be called once and triggers the pipeline
 // and watcher
 func (godev *GoDev) Start() {
+	if godev.config.RunConfig || godev.config.RunLogs {
+		// the config and logs sub-commands do their work in the cli
+		return
+	}
 	defer godev.logger.Infof("godev has ended")
 	godev.logger.Infof("godev has started")
 	if godev.config.RunDefault || godev.config.RunTest {
@@ -53,46 +63,85 @@ func (godev *GoDev) Start() {
 	}
 }
 
-func (godev *GoDev) createPipeline() []*ExecutionGroup {
+func (godev *GoDev) createPipeline() ([]*ExecutionGroup, error) {
 	var pipeline []*ExecutionGroup
-	for execGroupIndex, execGroup := range godev.config.ExecGroups {
-		executionGroup := &ExecutionGroup{}
+	pipelineConfigs, err := godev.config.getPipelineConfigs()
+	if err != nil {
+		return nil, err
+	}
+	names, dependencies, _ := godev.config.getExecGroupDependencies()
+	execGroups := godev.config.getExecGroups()
+	multiplexer := NewOutputMultiplexer(godev.config.OutputMode)
+	for index, commandConfigs := range pipelineConfigs {
+		executionGroup := &ExecutionGroup{
+			name:        names[index],
+			dependsOn:   dependencies[index],
+			background:  execGroups[index].Background,
+			readiness:   execGroups[index].getReadinessCheck(godev.config.WorkDirectory),
+			maxParallel: execGroups[index].getMaxParallel(godev.config.MaxParallel),
+		}
+		if len(execGroups[index].Inputs) > 0 {
+			executionGroup.cache = &ExecutionGroupCache{
+				Directory:     path.Join(godev.config.WorkDirectory, DefaultCacheDirectory),
+				Key:           names[index],
+				Inputs:        execGroups[index].Inputs,
+				Outputs:       execGroups[index].Outputs,
+				WorkDirectory: godev.config.WorkDirectory,
+			}
+		}
+		if len(execGroups[index].WhenChanged) > 0 {
+			executionGroup.changeFilter = &ChangeFilter{
+				Directory: godev.config.WatchDirectory,
+				Patterns:  execGroups[index].WhenChanged,
+			}
+		}
 		var executionCommands []*Command
-		commands := strings.Split(execGroup, godev.config.CommandsDelimiter)
-		for _, command := range commands {
-			if sections, err := shellquote.Split(command); err != nil {
-				panic(err)
-			} else {
-				arguments := sections[1:]
-				if execGroupIndex == len(godev.config.ExecGroups)-1 {
-					arguments = append(arguments, godev.config.CommandArguments...)
-				}
-				executionCommands = append(
-					executionCommands,
-					InitCommand(&CommandConfig{
-						Application: sections[0],
-						Arguments:   arguments,
-						Directory:   godev.config.WorkDirectory,
-						Environment: godev.config.EnvVars,
-						LogLevel:    godev.config.LogLevel,
-					}),
-				)
+		for commandIndex, commandConfig := range commandConfigs {
+			command := InitCommand(commandConfig)
+			command.multiplexer = multiplexer
+			if index == len(pipelineConfigs)-1 {
+				command.outputMode = godev.config.getFinalOutputMode()
+			} else if executionGroup.background {
+				command.outputMode = godev.config.getLongRunningOutputMode()
+			}
+			if index == len(pipelineConfigs)-1 && commandIndex == 0 {
+				command.stdin = godev.stdin
 			}
+			executionCommands = append(executionCommands, command)
 		}
 		executionGroup.commands = executionCommands
 		pipeline = append(pipeline, executionGroup)
 	}
-	return pipeline
+	return pipeline, nil
 }
 
 func (godev *GoDev) eventHandler(events *[]WatcherEvent) bool {
+	envFilesChanged := false
 	for _, e := range *events {
 		godev.logger.Trace(e)
+		if sliceContainsString(godev.config.EnvFiles, e.FilePath()) {
+			envFilesChanged = true
+		}
 	}
-	godev.runner.Trigger()
+	if envFilesChanged {
+		godev.reloadPipeline()
+	}
+	godev.runner.Trigger(InitChangeset(events))
 	return true
 }
 
+// reloadPipeline recreates the pipeline so that changes to env files
+// are picked up, the current pipeline is kept if that fails
+func (godev *GoDev) reloadPipeline() {
+	pipeline, err := godev.createPipeline()
+	if err != nil {
+		godev.logger.Errorf("keeping the previous environment: %s", err)
+		return
+	}
+	godev.logger.Infof("env file(s) changed, reloading environment")
+	godev.runner.SetPipeline(pipeline)
+}
+
 func (godev *GoDev) initialiseInitialisers() []Initialiser {
 	return []Initialiser{
 		InitGitInitialiser(&GitInitialiserConfig{
@@ -157,21 +206,37 @@ func (godev *GoDev) initialiseDirectory() {
 	}
 }
 
-func (godev *GoDev) initialiseRunner() {
+func (godev *GoDev) initialiseRunner() error {
+	pipeline, err := godev.createPipeline()
+	if err != nil {
+		return err
+	}
 	godev.runner = InitRunner(&RunnerConfig{
-		Pipeline: godev.createPipeline(),
-		LogLevel: godev.config.LogLevel,
+		Pipeline:          pipeline,
+		LogLevel:          godev.config.LogLevel,
+		RestartAlways:     godev.config.RestartAlways,
+		RestartPolicy:     godev.config.RestartPolicy,
+		CrashLoopExits:    godev.config.CrashLoopExits,
+		CrashLoopWindow:   godev.config.CrashLoopWindow,
+		Swap:              godev.config.Swap,
+		BuildOutput:       godev.config.BuildOutput,
+		StagedBuildOutput: godev.config.getStagedBuildOutput(),
+		WorkDirectory:     godev.config.WorkDirectory,
+		Logs:              godev.config.getRunLogs(),
 	})
+	return nil
 }
 
 func (godev *GoDev) initialiseWatcher() {
 	godev.watcher = InitWatcher(&WatcherConfig{
 		FileExtensions: godev.config.FileExtensions,
+		FilePaths:      godev.config.EnvFiles,
 		IgnoredNames:   godev.config.IgnoredNames,
 		RefreshRate:    godev.config.Rate,
 		LogLevel:       godev.config.LogLevel,
 	})
 	godev.watcher.RecursivelyWatch(godev.config.WatchDirectory)
+	godev.watcher.WatchFiles(godev.config.EnvFiles)
 }
 
 func (godev *GoDev) logUniversalConfigurations() {
@@ -187,25 +252,35 @@ func (godev *GoDev) logWatchModeConfigurations() {
 	config := godev.config
 	logger := godev.logger
 	logger.Debugf("environment       : %v", config.EnvVars)
+	logger.Debugf("env files         : %v", config.EnvFiles)
+	logger.Debugf("env inherit       : %s", config.EnvInherit)
 	logger.Debugf("file extensions   : %v", config.FileExtensions)
 	logger.Debugf("ignored names     : %v", config.IgnoredNames)
 	logger.Debugf("refresh interval  : %v", config.Rate)
 	logger.Debugf("execution delim   : %s", config.CommandsDelimiter)
+	logger.Debugf("swap              : %v", config.Swap)
+	logger.Debugf("restart always    : %v", config.RestartAlways)
+	logger.Debugf("restart policy    : %s", config.RestartPolicy)
+	logger.Debugf("crash loop        : %v exits in %s", config.CrashLoopExits, config.CrashLoopWindow)
+	logger.Debugf("stop signals      : %s", config.StopSignals)
+	logger.Debugf("logs              : %v runs, up to %s", config.LogsKeepRuns, config.LogsMaxSize)
+	logger.Debugf("max parallel      : %v", config.MaxParallel)
+	logger.Debugf("output mode       : %s", config.OutputMode)
+	logger.Debugf("pty               : %v", config.PTY)
+	logger.Debugf("stdin             : %v", config.Stdin)
 	logger.Debug("execution groups as follows...")
-	for execGroupIndex, execGroup := range config.ExecGroups {
+	// problems are reported when the runner is initialised
+	pipelineConfigs, _ := config.getPipelineConfigs()
+	names, dependencies, _ := config.getExecGroupDependencies()
+	for execGroupIndex, execGroup := range config.getExecGroups() {
 		logger.Debugf("  %v) %s", execGroupIndex+1, execGroup)
-		commands := strings.Split(execGroup, config.CommandsDelimiter)
-		for commandIndex, command := range commands {
-			sections, err := shellquote.Split(command)
-			if err != nil {
-				panic(err)
-			}
-			application := sections[0]
-			arguments := sections[1:]
-			if execGroupIndex == len(config.ExecGroups)-1 {
-				arguments = append(arguments, config.CommandArguments...)
-			}
-			logger.Debugf("    %v > %s %v", commandIndex+1, application, arguments)
+		var dependsOn []string
+		for _, dependency := range dependencies[execGroupIndex] {
+			dependsOn = append(dependsOn, names[dependency])
+		}
+		logger.Debugf("    name: %s, depends on: [%s]", names[execGroupIndex], strings.Join(dependsOn, ", "))
+		for commandIndex, commandConfig := range pipelineConfigs[execGroupIndex] {
+			logger.Debugf("    %v > %s %v (in %s)", commandIndex+1, commandConfig.Application, commandConfig.Arguments, commandConfig.Directory)
 		}
 	}
 }
@@ -213,13 +288,26 @@ func (godev *GoDev) logWatchModeConfigurations() {
 func (godev *GoDev) startWatching() {
 	godev.logUniversalConfigurations()
 	godev.logWatchModeConfigurations()
+	// stdin is only read in watch mode so that it isn't taken from
+	// the prompts of godev init
+	if godev.config.Stdin {
+		godev.stdin = NewStdinForwarder(os.Stdin, godev.config.LogLevel)
+		go godev.stdin.Forward()
+	}
+	if err := godev.initialiseRunner(); err != nil {
+		godev.logger.Error(err)
+		os.Exit(1)
+	}
 	godev.initialiseWatcher()
-	godev.initialiseRunner()
 
+	signals := make(chan os.Signal, 1)
+	notifySignals(signals)
+	defer signal.Stop(signals)
 	var wg sync.WaitGroup
 	godev.watcher.BeginWatch(&wg, godev.eventHandler)
 	godev.logger.Infof("working dir : '%s'", godev.config.WorkDirectory)
 	godev.logger.Infof("watching dir: '%s'", godev.config.WatchDirectory)
-	godev.runner.Trigger()
+	godev.runner.Trigger(nil)
+	godev.shutdown(godev.handleSignals(signals), signals)
 	wg.Wait()
 }
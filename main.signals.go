@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+const (
+	// ExitCodePipelineFailed is the exit code when godev shuts down
+	// after the last pipeline run failed
+	ExitCodePipelineFailed = 1
+	// ExitCodeForcedShutdown is the exit code when godev is made to exit
+	// before its commands have stopped
+	ExitCodeForcedShutdown = 2
+)
+
+// ShutdownSignals make godev stop its commands and exit
+var ShutdownSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+// notifySignals relays the signals which godev handles to :signals
+func notifySignals(signals chan os.Signal) {
+	handledSignals := append([]os.Signal{}, ShutdownSignals...)
+	handledSignals = append(handledSignals, RebuildSignals...)
+	handledSignals = append(handledSignals, RestartSignals...)
+	signal.Notify(signals, handledSignals...)
+}
+
+// handleSignals rebuilds or restarts the application when told to and
+// returns the signal telling godev to shut down
+func (godev *GoDev) handleSignals(signals chan os.Signal) os.Signal {
+	for {
+		receivedSignal := <-signals
+		switch {
+		case isSignalIn(receivedSignal, RebuildSignals):
+			godev.logger.Infof("received %s, rebuilding", receivedSignal)
+			godev.runner.Trigger(nil)
+		case isSignalIn(receivedSignal, RestartSignals):
+			godev.logger.Infof("received %s, restarting the final execution group", receivedSignal)
+			godev.runner.Restart()
+		case isSignalIn(receivedSignal, ShutdownSignals):
+			return receivedSignal
+		}
+	}
+}
+
+// shutdown stops the watcher and the commands which are running, logs
+// a summary and sets the exit code based on the last pipeline run. The
+// shutdown is forced if another shutdown signal arrives on :signals
+func (godev *GoDev) shutdown(receivedSignal os.Signal, signals chan os.Signal) {
+	godev.logger.Infof("received %s, stopping (send it again to exit immediately)...", receivedSignal)
+	go func() {
+		for receivedSignal := range signals {
+			if isSignalIn(receivedSignal, ShutdownSignals) {
+				godev.logger.Warnf("received %s again, exiting without waiting for commands to stop", receivedSignal)
+				os.Exit(ExitCodeForcedShutdown)
+			}
+		}
+	}()
+	godev.watcher.EndWatch()
+	godev.watcher.Close()
+	run := godev.runner.GetRun()
+	status := PipelineRunStatusSucceeded
+	if run != nil {
+		status = run.Status
+	}
+	godev.runner.Shutdown()
+	if status == PipelineRunStatusFailed {
+		godev.exitCode = ExitCodePipelineFailed
+		var failures []string
+		for _, result := range run.Results {
+			if result.IsFailure() {
+				failures = append(failures, result.String())
+			}
+		}
+		godev.logger.Errorf("stopped after %v pipeline run(s), the last one failed:\n  %s", run.GetID(), strings.Join(failures, "\n  "))
+		return
+	}
+	godev.logger.Infof("stopped after %v pipeline run(s)", run.GetID())
+}
+
+// isSignalIn checks whether :search is one of :signals
+func isSignalIn(search os.Signal, signals []os.Signal) bool {
+	for _, signal := range signals {
+		if signal == search {
+			return true
+		}
+	}
+	return false
+}
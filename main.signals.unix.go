@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// RebuildSignals make godev run the pipeline again
+var RebuildSignals = []os.Signal{syscall.SIGUSR1}
+
+// RestartSignals make godev restart the final execution group only
+var RestartSignals = []os.Signal{syscall.SIGUSR2}
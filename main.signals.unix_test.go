@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *MainSignalsTestSuite) Test_handleSignals() {
+	t := s.T()
+	signals := make(chan os.Signal, 3)
+	signals <- syscall.SIGUSR1
+	signals <- syscall.SIGUSR2
+	signals <- syscall.SIGTERM
+	assert.Equal(t, syscall.SIGTERM, s.godev.handleSignals(signals))
+	s.godev.runner.Shutdown()
+	assert.Contains(t, s.logs.String(), "received user defined signal 1, rebuilding")
+	assert.Contains(t, s.logs.String(), "received user defined signal 2, restarting the final execution group")
+}
+
+func (s *MainSignalsTestSuite) Test_isSignalIn_userDefinedSignals() {
+	t := s.T()
+	assert.True(t, isSignalIn(syscall.SIGUSR1, RebuildSignals))
+	assert.True(t, isSignalIn(syscall.SIGUSR2, RestartSignals))
+	assert.False(t, isSignalIn(syscall.SIGUSR1, ShutdownSignals))
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os"
+)
+
+// RebuildSignals is empty as there are no user-defined signals
+var RebuildSignals = []os.Signal{}
+
+// RestartSignals is empty as there are no user-defined signals
+var RestartSignals = []os.Signal{}
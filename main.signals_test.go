@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MainSignalsTestSuite struct {
+	suite.Suite
+	godev         *GoDev
+	logs          bytes.Buffer
+	workDirectory string
+}
+
+func TestMainSignalsTestSuite(t *testing.T) {
+	suite.Run(t, new(MainSignalsTestSuite))
+}
+
+func (s *MainSignalsTestSuite) SetupTest() {
+	s.logs.Reset()
+	s.workDirectory, _ = ioutil.TempDir("", "godev-signals-")
+	s.godev = InitGoDev(&Config{
+		CommandsDelimiter: ",",
+		ExecGroups:        []string{"true"},
+		FileExtensions:    []string{"go"},
+		LogLevel:          "trace",
+		Rate:              time.Second,
+		StopSignals:       DefaultStopSignals,
+		WatchDirectory:    getCurrentWorkingDirectory(),
+		WorkDirectory:     s.workDirectory,
+	})
+	s.godev.logger.SetOutput(&s.logs)
+	s.godev.initialiseRunner()
+	s.godev.runner.logger.SetOutput(&s.logs)
+	s.godev.initialiseWatcher()
+	s.godev.watcher.logger.SetOutput(&s.logs)
+}
+
+func (s *MainSignalsTestSuite) TearDownTest() {
+	os.RemoveAll(s.workDirectory)
+}
+
+func (s *MainSignalsTestSuite) Test_shutdown() {
+	t := s.T()
+	var wg sync.WaitGroup
+	s.godev.watcher.BeginWatch(&wg, s.godev.eventHandler)
+	s.godev.runner.Trigger(nil)
+	s.godev.runner.waitGroup.Wait()
+	s.godev.shutdown(syscall.SIGINT, make(chan os.Signal))
+	wg.Wait()
+	assert.Equal(t, 0, s.godev.exitCode)
+	assert.Contains(t, s.logs.String(), "received interrupt, stopping")
+	assert.Contains(t, s.logs.String(), fmt.Sprintf("stopped after %v pipeline run(s)", s.godev.runner.GetRun().GetID()))
+}
+
+func (s *MainSignalsTestSuite) Test_shutdown_afterFailedRun() {
+	t := s.T()
+	s.godev.config.ExecGroups = []string{"false"}
+	s.godev.initialiseRunner()
+	s.godev.runner.logger.SetOutput(&s.logs)
+	var wg sync.WaitGroup
+	s.godev.watcher.BeginWatch(&wg, s.godev.eventHandler)
+	s.godev.runner.Trigger(nil)
+	s.godev.runner.waitGroup.Wait()
+	s.godev.shutdown(syscall.SIGTERM, make(chan os.Signal))
+	wg.Wait()
+	assert.Equal(t, ExitCodePipelineFailed, s.godev.exitCode)
+	assert.Contains(t, s.logs.String(), fmt.Sprintf("stopped after %v pipeline run(s), the last one failed", s.godev.runner.GetRun().GetID()))
+	assert.Contains(t, s.logs.String(), "'false' failed with exit code 1")
+}
+
+func (s *MainSignalsTestSuite) Test_isSignalIn() {
+	t := s.T()
+	assert.True(t, isSignalIn(syscall.SIGHUP, ShutdownSignals))
+	assert.False(t, isSignalIn(syscall.SIGKILL, ShutdownSignals))
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,7 +39,8 @@ func (s *MainTestSuite) SetupTest() {
 
 func (s *MainTestSuite) Test_createPipeline_assignsEnvVarsCorrectly() {
 	t := s.T()
-	pipeline := s.godev.createPipeline()
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, err)
 	for _, executionGroup := range pipeline {
 		for _, command := range executionGroup.commands {
 			assert.Len(t, command.config.Environment, 2)
@@ -48,7 +50,8 @@ func (s *MainTestSuite) Test_createPipeline_assignsEnvVarsCorrectly() {
 
 func (s *MainTestSuite) Test_createPipeline_separatesCommandsCorrectly() {
 	t := s.T()
-	pipeline := s.godev.createPipeline()
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, err)
 	assert.Len(t, pipeline[0].commands, 3)
 	assert.Len(t, pipeline[1].commands, 2)
 	assert.Len(t, pipeline[2].commands, 1)
@@ -56,7 +59,8 @@ func (s *MainTestSuite) Test_createPipeline_separatesCommandsCorrectly() {
 
 func (s *MainTestSuite) Test_createPipeline_separatesCommandArgsCorrectly() {
 	t := s.T()
-	pipeline := s.godev.createPipeline()
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, err)
 	// echo 'a b' c
 	assert.Len(t, pipeline[0].commands[0].config.Arguments, 2)
 	assert.Equal(t, "a b", pipeline[0].commands[0].config.Arguments[0])
@@ -81,6 +85,91 @@ func (s *MainTestSuite) Test_createPipeline_separatesCommandArgsCorrectly() {
 	assert.Equal(t, "arg", pipeline[2].commands[0].config.Arguments[2])
 }
 
+func (s *MainTestSuite) Test_createPipeline_assignsDependenciesCorrectly() {
+	t := s.T()
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, err)
+	assert.Equal(t, "1", pipeline[0].GetName())
+	assert.Nil(t, pipeline[0].dependsOn)
+	assert.Equal(t, "3", pipeline[2].GetName())
+	assert.Equal(t, []int{1}, pipeline[2].dependsOn)
+}
+
+func (s *MainTestSuite) Test_createPipeline_assignsMaxParallelCorrectly() {
+	t := s.T()
+	s.godev.config.MaxParallel = 2
+	s.godev.config.ExecGroupConfigs = ConfigExecGroups{
+		{Commands: []*ConfigExecCommand{{Run: "echo a"}, {Run: "echo b"}}},
+		{MaxParallel: 1, Commands: []*ConfigExecCommand{{Run: "echo c"}, {Run: "echo d"}}},
+	}
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pipeline[0].maxParallel)
+	assert.Equal(t, 1, pipeline[1].maxParallel)
+}
+
+func (s *MainTestSuite) Test_createPipeline_assignsOutputCorrectly() {
+	t := s.T()
+	s.godev.config.OutputMode = OutputModePrefixed
+	s.godev.config.ExecGroupConfigs = ConfigExecGroups{
+		{Commands: []*ConfigExecCommand{{Run: "echo a", Name: "a"}, {Run: "echo b"}}},
+		{Commands: []*ConfigExecCommand{{Run: "echo c"}}},
+	}
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, err)
+	multiplexer := pipeline[0].commands[0].multiplexer
+	assert.Equal(t, OutputModePrefixed, multiplexer.Mode)
+	assert.Equal(t, multiplexer, pipeline[0].commands[1].multiplexer)
+	assert.Equal(t, multiplexer, pipeline[1].commands[0].multiplexer)
+	assert.Equal(t, "a", pipeline[0].commands[0].GetName())
+	assert.Equal(t, pipeline[0].commands[1].GetID(), pipeline[0].commands[1].GetName())
+}
+
+func (s *MainTestSuite) Test_createPipeline_assignsOutputModeCorrectly() {
+	t := s.T()
+	s.godev.config.OutputMode = OutputModeGrouped
+	s.godev.config.ExecGroupConfigs = ConfigExecGroups{
+		{Commands: []*ConfigExecCommand{{Run: "echo a"}}},
+		{Background: true, Commands: []*ConfigExecCommand{{Run: "echo b"}}},
+		{Commands: []*ConfigExecCommand{{Run: "echo c"}}},
+	}
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, err)
+	assert.Empty(t, pipeline[0].commands[0].outputMode)
+	assert.Equal(t, OutputModePrefixed, pipeline[1].commands[0].outputMode)
+	assert.Equal(t, OutputModePrefixed, pipeline[2].commands[0].outputMode)
+	s.godev.config.Stdin = true
+	pipeline, err = s.godev.createPipeline()
+	assert.Nil(t, err)
+	assert.Equal(t, OutputModePrefixed, pipeline[1].commands[0].outputMode)
+	assert.Equal(t, OutputModeRaw, pipeline[2].commands[0].outputMode)
+}
+
+func (s *MainTestSuite) Test_createPipeline_assignsStdinCorrectly() {
+	t := s.T()
+	s.godev.stdin = NewStdinForwarder(&bytes.Buffer{}, "trace")
+	s.godev.config.ExecGroupConfigs = ConfigExecGroups{
+		{Commands: []*ConfigExecCommand{{Run: "echo a"}}},
+		{Commands: []*ConfigExecCommand{{Run: "echo b"}, {Run: "echo c"}}},
+	}
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, err)
+	assert.Nil(t, pipeline[0].commands[0].stdin)
+	assert.Equal(t, s.godev.stdin, pipeline[1].commands[0].stdin)
+	assert.Nil(t, pipeline[1].commands[1].stdin)
+}
+
+func (s *MainTestSuite) Test_createPipeline_withUnparseableCommand() {
+	t := s.T()
+	s.godev.config.ExecGroups = []string{"echo 'a", "echo b,"}
+	pipeline, err := s.godev.createPipeline()
+	assert.Nil(t, pipeline)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "found 2 problem(s)")
+	assert.Contains(t, err.Error(), "execution group 1, command 1 ('echo 'a') could not be parsed")
+	assert.Contains(t, err.Error(), "execution group 2, command 2 is empty")
+}
+
 func (s *MainTestSuite) Test_eventHandler() {
 	t := s.T()
 	// set exec groups to none so that no pipeline triggers
@@ -101,6 +190,20 @@ func (s *MainTestSuite) Test_eventHandler() {
 	assert.Contains(t, logs, "CHMOD")
 }
 
+func (s *MainTestSuite) Test_eventHandler_reloadsEnvFiles() {
+	t := s.T()
+	envFile := path.Join(getCurrentWorkingDirectory(), "/data/test-env/override.env")
+	s.godev.config.ExecGroups = []string{}
+	s.godev.initialiseRunner()
+	s.godev.config.ExecGroups = []string{"echo 1"}
+	s.godev.config.EnvFiles = []string{envFile}
+	s.godev.eventHandler(&[]WatcherEvent{WatcherEvent{Name: envFile, Op: 2}})
+	assert.Contains(t, s.logs.String(), "reloading environment")
+	environment := s.godev.runner.config.Pipeline[0].commands[0].config.Environment
+	assert.Contains(t, environment, "APP_ENV=production")
+	assert.Contains(t, environment, "A=1")
+}
+
 func (s *MainTestSuite) Test_initialiseInitialisers() {
 	t := s.T()
 	initialisers := s.godev.initialiseInitialisers()
@@ -118,6 +221,18 @@ func (s *MainTestSuite) Test_initialiseInitialisers() {
 	assert.Contains(t, keys, "go.mod")
 }
 
+func (s *MainTestSuite) Test_initialiseInitialisers_ignoresGodevDirectory() {
+	t := s.T()
+	var ignoreFiles []string
+	for _, initialiser := range s.godev.initialiseInitialisers() {
+		if fileInitialiser, ok := initialiser.(*FileInitialiser); ok && strings.HasSuffix(fileInitialiser.Key, "ignore") {
+			ignoreFiles = append(ignoreFiles, fileInitialiser.Key)
+			assert.Contains(t, strings.Split(string(fileInitialiser.Data), "\n"), ".godev", fileInitialiser.Key)
+		}
+	}
+	assert.Equal(t, []string{".gitignore", ".dockerignore"}, ignoreFiles)
+}
+
 func (s *MainTestSuite) Test_initialiseRunner() {
 	t := s.T()
 	assert.Nil(t, s.godev.runner)
@@ -125,6 +240,18 @@ func (s *MainTestSuite) Test_initialiseRunner() {
 	assert.NotNil(t, s.godev.runner)
 }
 
+func (s *MainTestSuite) Test_initialiseRunner_withRunLogs() {
+	t := s.T()
+	s.godev.initialiseRunner()
+	assert.Nil(t, s.godev.runner.config.Logs)
+	s.godev.config.LogsKeepRuns = 10
+	s.godev.config.LogsMaxSize = "1MB"
+	s.godev.initialiseRunner()
+	logs := s.godev.runner.config.Logs
+	assert.NotEmpty(t, logs.Session)
+	assert.Equal(t, &RunLogs{Directory: "/work/directory/.godev/logs", Session: logs.Session, MaxRuns: 10, MaxSize: 1 << 20}, logs)
+}
+
 func (s *MainTestSuite) Test_initialiseWatcher() {
 	t := s.T()
 	s.godev.config.FileExtensions = []string{"a", "b", "c"}
@@ -184,3 +311,18 @@ func (s *MainTestSuite) Test_logWatchModeConfigurations() {
 	assert.Contains(t, logs, "3) echo ''")
 	assert.Contains(t, logs, "test arg")
 }
+
+func (s *MainTestSuite) Test_logWatchModeConfigurations_withConfigFileGroups() {
+	t := s.T()
+	s.godev.config.ExecGroups = nil
+	s.godev.config.ExecGroupConfigs = ConfigExecGroups{
+		{Name: "build", Commands: []*ConfigExecCommand{{Run: "go build -o bin/app"}}},
+		{Commands: []*ConfigExecCommand{{Run: "bin/app"}}},
+	}
+	s.godev.logWatchModeConfigurations()
+	logs := s.logs.String()
+	assert.Contains(t, logs, "1) go build -o bin/app")
+	assert.Contains(t, logs, "name: build, depends on: []")
+	assert.Contains(t, logs, "2) bin/app")
+	assert.Contains(t, logs, "name: 2, depends on: [build]")
+}
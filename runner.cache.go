@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultCacheDirectory is where execution group caches are stored
+// relative to the work directory
+const DefaultCacheDirectory = ".godev/cache"
+
+// ExecutionGroupCache allows an execution group to be skipped when the
+// files matching its Inputs haven't changed since it last succeeded and
+// its Outputs still exist. Inputs are globs where ** matches any number
+// of directories, both are relative to the WorkDirectory
+type ExecutionGroupCache struct {
+	Directory     string
+	Key           string
+	Inputs        []string
+	Outputs       []string
+	WorkDirectory string
+}
+
+// Hash computes a hash over the files matching the inputs and :salt
+// which should describe what the execution group runs
+func (cache *ExecutionGroupCache) Hash(salt []string) (string, error) {
+	hash := sha256.New()
+	for _, value := range salt {
+		fmt.Fprintf(hash, "%s\x00", value)
+	}
+	filePaths, err := expandGlobs(cache.WorkDirectory, cache.Inputs)
+	if err != nil {
+		return "", err
+	}
+	for _, filePath := range filePaths {
+		fmt.Fprintf(hash, "%s\x00", filePath)
+		file, err := os.Open(path.Join(cache.WorkDirectory, filePath))
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// IsFresh checks whether :hash is the hash of the last successful run
+// and all outputs are still around
+func (cache *ExecutionGroupCache) IsFresh(hash string) bool {
+	previousHash, err := ioutil.ReadFile(cache.getFilePath())
+	if err != nil || string(previousHash) != hash {
+		return false
+	}
+	for _, output := range cache.Outputs {
+		if _, err := os.Stat(resolvePath(cache.WorkDirectory, output)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Save stores :hash as the hash of the last successful run
+func (cache *ExecutionGroupCache) Save(hash string) error {
+	if err := os.MkdirAll(cache.Directory, os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cache.getFilePath(), []byte(hash), 0644)
+}
+
+// getFilePath returns the path of the file storing the hash
+func (cache *ExecutionGroupCache) getFilePath() string {
+	return path.Join(cache.Directory, fmt.Sprintf("%x", sha256.Sum256([]byte(cache.Key)))[:16])
+}
+
+// expandGlobs returns the regular files in :directory matching
+// :patterns as sorted paths relative to :directory
+func expandGlobs(directory string, patterns []string) ([]string, error) {
+	matches := map[string]bool{}
+	for _, pattern := range patterns {
+		pattern = path.Clean(pattern)
+		if path.IsAbs(pattern) || strings.HasPrefix(pattern, "../") {
+			return nil, fmt.Errorf("input '%s' should be relative to '%s'", pattern, directory)
+		}
+		root := getGlobRoot(pattern)
+		err := filepath.Walk(path.Join(directory, root), func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			relativePath, _ := filepath.Rel(directory, filePath)
+			relativePath = filepath.ToSlash(relativePath)
+			if info.IsDir() {
+				if info.Name() == path.Dir(DefaultCacheDirectory) && relativePath != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Mode().IsRegular() && matchGlob(strings.Split(pattern, "/"), strings.Split(relativePath, "/")) {
+				matches[relativePath] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	filePaths := []string{}
+	for filePath := range matches {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	return filePaths, nil
+}
+
+// getGlobRoot returns the leading directories of :pattern which
+// don't contain wildcards
+func getGlobRoot(pattern string) string {
+	var root []string
+	segments := strings.Split(pattern, "/")
+	for _, segment := range segments[:len(segments)-1] {
+		if strings.ContainsAny(segment, "*?[\\") {
+			break
+		}
+		root = append(root, segment)
+	}
+	return path.Join(append([]string{"."}, root...)...)
+}
+
+// matchGlob matches path :segments against glob :patternSegments
+// where a ** segment matches any number of segments
+func matchGlob(patternSegments []string, segments []string) bool {
+	if len(patternSegments) == 0 {
+		return len(segments) == 0
+	} else if patternSegments[0] == "**" {
+		for index := 0; index <= len(segments); index++ {
+			if matchGlob(patternSegments[1:], segments[index:]) {
+				return true
+			}
+		}
+		return false
+	} else if len(segments) == 0 {
+		return false
+	}
+	matched, _ := path.Match(patternSegments[0], segments[0])
+	return matched && matchGlob(patternSegments[1:], segments[1:])
+}
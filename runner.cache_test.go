@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExecutionGroupCacheTestSuite struct {
+	suite.Suite
+	workDirectory string
+	cache         *ExecutionGroupCache
+}
+
+func TestExecutionGroupCache(t *testing.T) {
+	suite.Run(t, new(ExecutionGroupCacheTestSuite))
+}
+
+func (s *ExecutionGroupCacheTestSuite) SetupTest() {
+	workDirectory, err := ioutil.TempDir("", "godev-cache-")
+	if err != nil {
+		panic(err)
+	}
+	s.workDirectory = workDirectory
+	s.writeFile("go.mod", "module app")
+	s.writeFile("api/v1/kind.proto", "message Kind {}")
+	s.writeFile("api/handler.go", "package api")
+	s.cache = &ExecutionGroupCache{
+		Directory:     path.Join(workDirectory, DefaultCacheDirectory),
+		Key:           "generate",
+		Inputs:        []string{"api/**/*.proto", "go.mod"},
+		WorkDirectory: workDirectory,
+	}
+}
+
+func (s *ExecutionGroupCacheTestSuite) TearDownTest() {
+	os.RemoveAll(s.workDirectory)
+}
+
+func (s *ExecutionGroupCacheTestSuite) writeFile(filePath, contents string) {
+	fullPath := path.Join(s.workDirectory, filePath)
+	if err := os.MkdirAll(path.Dir(fullPath), os.ModePerm); err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(fullPath, []byte(contents), 0644); err != nil {
+		panic(err)
+	}
+}
+
+func (s *ExecutionGroupCacheTestSuite) TestHash() {
+	t := s.T()
+	hash, err := s.cache.Hash([]string{"go", "generate"})
+	assert.Nil(t, err)
+	sameHash, _ := s.cache.Hash([]string{"go", "generate"})
+	assert.Equal(t, hash, sameHash)
+	saltedHash, _ := s.cache.Hash([]string{"go", "build"})
+	assert.NotEqual(t, hash, saltedHash)
+	s.writeFile("api/handler.go", "package api // changed")
+	unrelatedHash, _ := s.cache.Hash([]string{"go", "generate"})
+	assert.Equal(t, hash, unrelatedHash)
+	s.writeFile("api/v1/kind.proto", "message Kind { string name = 1; }")
+	changedHash, _ := s.cache.Hash([]string{"go", "generate"})
+	assert.NotEqual(t, hash, changedHash)
+}
+
+func (s *ExecutionGroupCacheTestSuite) TestHash_withInvalidInput() {
+	s.cache.Inputs = []string{"../*.go"}
+	_, err := s.cache.Hash(nil)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *ExecutionGroupCacheTestSuite) TestIsFresh() {
+	t := s.T()
+	hash, _ := s.cache.Hash(nil)
+	assert.False(t, s.cache.IsFresh(hash))
+	assert.Nil(t, s.cache.Save(hash))
+	assert.True(t, s.cache.IsFresh(hash))
+	assert.False(t, s.cache.IsFresh("another hash"))
+}
+
+func (s *ExecutionGroupCacheTestSuite) TestIsFresh_withMissingOutputs() {
+	t := s.T()
+	s.cache.Outputs = []string{"api/generated"}
+	hash, _ := s.cache.Hash(nil)
+	s.cache.Save(hash)
+	assert.False(t, s.cache.IsFresh(hash))
+	s.writeFile("api/generated/kind.pb.go", "package generated")
+	assert.True(t, s.cache.IsFresh(hash))
+}
+
+func (s *ExecutionGroupCacheTestSuite) Test_expandGlobs() {
+	t := s.T()
+	s.writeFile(".godev/cache/go.mod", "ignored")
+	filePaths, err := expandGlobs(s.workDirectory, []string{"**/*.go", "**/go.mod", "missing/*"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api/handler.go", "go.mod"}, filePaths)
+}
+
+func (s *ExecutionGroupCacheTestSuite) Test_getGlobRoot() {
+	t := s.T()
+	assert.Equal(t, ".", getGlobRoot("go.mod"))
+	assert.Equal(t, "api", getGlobRoot("api/**/*.proto"))
+	assert.Equal(t, "api/v1", getGlobRoot("api/v1/*.proto"))
+}
+
+func (s *ExecutionGroupCacheTestSuite) Test_matchGlob() {
+	t := s.T()
+	matches := func(pattern, filePath string) bool {
+		return matchGlob(strings.Split(pattern, "/"), strings.Split(filePath, "/"))
+	}
+	assert.True(t, matches("**/*.go", "main.go"))
+	assert.True(t, matches("**/*.go", "a/b/main.go"))
+	assert.True(t, matches("api/**", "api/v1/kind.proto"))
+	assert.True(t, matches("api/*/kind.proto", "api/v1/kind.proto"))
+	assert.False(t, matches("api/*.proto", "api/v1/kind.proto"))
+	assert.False(t, matches("*.go", "main.proto"))
+}
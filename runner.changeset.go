@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Changeset holds the files which changed between pipeline runs
+type Changeset struct {
+	Created  []string
+	Modified []string
+	Removed  []string
+	Renamed  []string
+}
+
+// InitChangeset creates a changeset from a batch of watcher events, a
+// file which was created and then modified is only reported as created,
+// otherwise the last event for a file wins. Permission changes are ignored
+func InitChangeset(events *[]WatcherEvent) *Changeset {
+	changeset := &Changeset{}
+	if events == nil {
+		return changeset
+	}
+	var filePaths []string
+	changes := map[string]fsnotify.Op{}
+	for _, event := range *events {
+		var change fsnotify.Op
+		switch {
+		case event.Op&fsnotify.Remove == fsnotify.Remove:
+			change = fsnotify.Remove
+		case event.Op&fsnotify.Rename == fsnotify.Rename:
+			change = fsnotify.Rename
+		case event.Op&fsnotify.Create == fsnotify.Create:
+			change = fsnotify.Create
+		case event.Op&fsnotify.Write == fsnotify.Write:
+			change = fsnotify.Write
+		default:
+			continue
+		}
+		previousChange, ok := changes[event.Name]
+		if !ok {
+			filePaths = append(filePaths, event.Name)
+		} else if previousChange == fsnotify.Create && change == fsnotify.Write {
+			continue
+		}
+		changes[event.Name] = change
+	}
+	sort.Strings(filePaths)
+	for _, filePath := range filePaths {
+		switch changes[filePath] {
+		case fsnotify.Create:
+			changeset.Created = append(changeset.Created, filePath)
+		case fsnotify.Write:
+			changeset.Modified = append(changeset.Modified, filePath)
+		case fsnotify.Remove:
+			changeset.Removed = append(changeset.Removed, filePath)
+		case fsnotify.Rename:
+			changeset.Renamed = append(changeset.Renamed, filePath)
+		}
+	}
+	return changeset
+}
+
+// Files returns all changed files in alphabetical order
+func (changeset *Changeset) Files() []string {
+	files := []string{}
+	if changeset == nil {
+		return files
+	}
+	files = append(files, changeset.Created...)
+	files = append(files, changeset.Modified...)
+	files = append(files, changeset.Removed...)
+	files = append(files, changeset.Renamed...)
+	sort.Strings(files)
+	return files
+}
+
+// IsEmpty checks whether any files changed, this is the case for the
+// run triggered when godev starts
+func (changeset *Changeset) IsEmpty() bool {
+	return len(changeset.Files()) == 0
+}
+
+// ChangePattern matches changed files by a glob :Pattern, which is
+// matched against the file name if it has no '/' and otherwise against
+// the path relative to the watched directory. If :Contains is set, the
+// changed file also has to contain it (eg. '//go:generate')
+type ChangePattern struct {
+	Pattern  string `yaml:"pattern" toml:"pattern"`
+	Contains string `yaml:"contains" toml:"contains"`
+}
+
+// Matches checks whether :filePath in :directory matches the pattern
+func (pattern *ChangePattern) Matches(directory string, filePath string) bool {
+	target := path.Base(filePath)
+	if strings.Contains(pattern.Pattern, "/") {
+		relativePath, err := filepath.Rel(directory, filePath)
+		if err != nil {
+			return false
+		}
+		target = filepath.ToSlash(relativePath)
+	}
+	if matched, _ := path.Match(pattern.Pattern, target); !matched {
+		return false
+	}
+	if len(pattern.Contains) == 0 {
+		return true
+	}
+	contents, err := ioutil.ReadFile(filePath)
+	return err == nil && strings.Contains(string(contents), pattern.Contains)
+}
+
+// String returns the pattern as it is displayed
+func (pattern *ChangePattern) String() string {
+	if len(pattern.Contains) > 0 {
+		return fmt.Sprintf("%s containing '%s'", pattern.Pattern, pattern.Contains)
+	}
+	return pattern.Pattern
+}
+
+// validate checks that the pattern is a valid glob
+func (pattern *ChangePattern) validate() error {
+	if len(pattern.Pattern) == 0 {
+		return fmt.Errorf("file pattern should specify 'pattern'")
+	} else if _, err := path.Match(pattern.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid file pattern '%s': %s", pattern.Pattern, err)
+	}
+	return nil
+}
+
+// ChangeFilter decides whether an execution group should run for a
+// changeset based on the files it cares about
+type ChangeFilter struct {
+	Directory string
+	Patterns  []*ChangePattern
+}
+
+// Matches checks whether any file in :changeset matches the patterns,
+// a nil changeset is the run when godev starts which always matches
+func (filter *ChangeFilter) Matches(changeset *Changeset) bool {
+	if filter == nil || len(filter.Patterns) == 0 || changeset == nil {
+		return true
+	}
+	for _, filePath := range changeset.Files() {
+		for _, pattern := range filter.Patterns {
+			if pattern.Matches(filter.Directory, filePath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String returns the patterns as they are displayed
+func (filter *ChangeFilter) String() string {
+	var patterns []string
+	for _, pattern := range filter.Patterns {
+		patterns = append(patterns, pattern.String())
+	}
+	return strings.Join(patterns, ", ")
+}
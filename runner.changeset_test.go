@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChangesetTestSuite struct {
+	suite.Suite
+}
+
+func TestChangesetTestSuite(t *testing.T) {
+	suite.Run(t, new(ChangesetTestSuite))
+}
+
+func (s *ChangesetTestSuite) TestInitChangeset() {
+	t := s.T()
+	changeset := InitChangeset(&[]WatcherEvent{
+		WatcherEvent{Name: "/b.go", Op: fsnotify.Write},
+		WatcherEvent{Name: "/a.go", Op: fsnotify.Create},
+		WatcherEvent{Name: "/a.go", Op: fsnotify.Write},
+		WatcherEvent{Name: "/c.go", Op: fsnotify.Write},
+		WatcherEvent{Name: "/c.go", Op: fsnotify.Remove},
+		WatcherEvent{Name: "/d.go", Op: fsnotify.Rename},
+		WatcherEvent{Name: "/e.go", Op: fsnotify.Chmod},
+	})
+	assert.Equal(t, []string{"/a.go"}, changeset.Created)
+	assert.Equal(t, []string{"/b.go"}, changeset.Modified)
+	assert.Equal(t, []string{"/c.go"}, changeset.Removed)
+	assert.Equal(t, []string{"/d.go"}, changeset.Renamed)
+	assert.Equal(t, []string{"/a.go", "/b.go", "/c.go", "/d.go"}, changeset.Files())
+	assert.False(t, changeset.IsEmpty())
+}
+
+func (s *ChangesetTestSuite) TestInitChangeset_withoutEvents() {
+	t := s.T()
+	assert.True(t, InitChangeset(nil).IsEmpty())
+	assert.True(t, InitChangeset(&[]WatcherEvent{}).IsEmpty())
+	var changeset *Changeset
+	assert.Equal(t, []string{}, changeset.Files())
+}
+
+func (s *ChangesetTestSuite) TestChangePattern_Matches() {
+	t := s.T()
+	directory := path.Join(getCurrentWorkingDirectory(), "/data/test-changes")
+	generatedFile := path.Join(directory, "/api/kind.txt")
+	handlerFile := path.Join(directory, "/api/handler.txt")
+	assert.True(t, (&ChangePattern{Pattern: "*.txt"}).Matches(directory, handlerFile))
+	assert.True(t, (&ChangePattern{Pattern: "api/*.txt"}).Matches(directory, handlerFile))
+	assert.False(t, (&ChangePattern{Pattern: "*/api/*.txt"}).Matches(directory, handlerFile))
+	assert.False(t, (&ChangePattern{Pattern: "go.mod"}).Matches(directory, handlerFile))
+	assert.True(t, (&ChangePattern{Pattern: "*.txt", Contains: "//go:generate"}).Matches(directory, generatedFile))
+	assert.False(t, (&ChangePattern{Pattern: "*.txt", Contains: "//go:generate"}).Matches(directory, handlerFile))
+	assert.False(t, (&ChangePattern{Pattern: "*.txt", Contains: "//go:generate"}).Matches(directory, path.Join(directory, "/removed.txt")))
+}
+
+func (s *ChangesetTestSuite) TestChangeFilter_Matches() {
+	t := s.T()
+	filter := &ChangeFilter{
+		Directory: "/work",
+		Patterns:  []*ChangePattern{{Pattern: "go.mod"}, {Pattern: "go.sum"}},
+	}
+	assert.True(t, filter.Matches(nil))
+	assert.True(t, filter.Matches(&Changeset{Modified: []string{"/work/go.sum"}}))
+	assert.False(t, filter.Matches(&Changeset{Modified: []string{"/work/main.go"}}))
+	assert.False(t, filter.Matches(&Changeset{}))
+	assert.Equal(t, "go.mod, go.sum", filter.String())
+	var noFilter *ChangeFilter
+	assert.True(t, noFilter.Matches(&Changeset{}))
+}
@@ -2,25 +2,60 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"strings"
 	"sync"
+	"time"
 )
 
-// RunnerConfig configures the Runner
+// RunnerConfig configures the Runner, when Swap is set the final
+// execution group keeps running until the execution groups before it
+// have succeeded, StagedBuildOutput is then moved to BuildOutput
+// before the final execution group is restarted. A final execution
+// group which runs the BuildOutput also keeps running without Swap
+// until the build succeeds, when it's stopped and only restarted if the
+// build output changed unless RestartAlways is set. Relative paths are
+// resolved against the WorkDirectory. The final execution
+// group is restarted when it exits on its own according to the
+// RestartPolicy until it exits CrashLoopExits times within the
+// CrashLoopWindow. The output of the commands in each run is logged to
+// files by Logs when it's set
 type RunnerConfig struct {
-	Pipeline []*ExecutionGroup
-	LogLevel LogLevel
+	Pipeline          []*ExecutionGroup
+	LogLevel          LogLevel
+	RestartAlways     bool
+	RestartPolicy     string
+	CrashLoopExits    int
+	CrashLoopWindow   time.Duration
+	Swap              bool
+	BuildOutput       string
+	StagedBuildOutput string
+	WorkDirectory     string
+	Logs              *RunLogs
 }
 
 // RunnerTriggerCount keeps track of the number of piplines run
 var RunnerTriggerCount = 0
 
-// Runner is the main component responsible for running the execution pipeline
+// Runner is the main component responsible for running the execution
+// pipeline, its mutex serialises triggers and guards the latest run.
+// Each run is started in the background once the run before it is done,
+// which is when done is closed. The changeset files of changesetRuns
+// are kept until none of their execution groups are running
 type Runner struct {
-	config    *RunnerConfig
-	logger    *Logger
-	waitGroup sync.WaitGroup
-	started   bool
-	stopped   bool
+	config           *RunnerConfig
+	logger           *Logger
+	mutex            sync.Mutex
+	run              *PipelineRun
+	done             chan bool
+	changesetRuns    []*PipelineRun
+	restarts         *RestartTracker
+	runningBuildHash string
+	waitGroup        sync.WaitGroup
+	started          bool
+	stopped          bool
+	isShutdown       bool
 }
 
 // InitRunner initialises a runner
@@ -32,53 +67,587 @@ func InitRunner(config *RunnerConfig) *Runner {
 			Format: "production",
 			Level:  config.LogLevel},
 		),
+		restarts: &RestartTracker{
+			Policy:          config.RestartPolicy,
+			CrashLoopExits:  config.CrashLoopExits,
+			CrashLoopWindow: config.CrashLoopWindow,
+		},
 		started: false,
 		stopped: false,
 	}
 	return runner
 }
 
-func (runner *Runner) startPipeline() {
+// nextRun replaces the latest run with a new one for the files in
+// :changeset which only runs the final execution group when :finalOnly
+// is set and returns it. The mutex must be held when it's called
+func (runner *Runner) nextRun(changeset *Changeset, finalOnly bool) *PipelineRun {
 	RunnerTriggerCount++
-	defer runner.logger.Tracef("completed pipeline %v", RunnerTriggerCount)
-	runner.logger.Tracef("starting pipeline %v", RunnerTriggerCount)
-	executionGroupCount := len(runner.config.Pipeline)
+	run := InitPipelineRun(RunnerTriggerCount, changeset)
+	run.FinalOnly = finalOnly
+	runner.run = run
+	return run
+}
+
+// startInBackground starts :run in the background once the run before
+// it is done so that two runs never drive the execution groups at the
+// same time. The mutex must be held when it's called
+func (runner *Runner) startInBackground(run *PipelineRun) {
+	previousDone := runner.done
+	done := make(chan bool)
+	runner.done = done
+	runner.waitGroup.Add(1)
+	go func() {
+		defer runner.waitGroup.Done()
+		defer close(done)
+		if previousDone != nil {
+			<-previousDone
+		}
+		runner.start(run)
+	}()
+}
+
+// start runs the pipeline for :run and logs its summary
+func (runner *Runner) start(run *PipelineRun) {
+	defer runner.logger.Tracef("completed pipeline %v", run.ID)
+	runner.logger.Tracef("starting pipeline %v", run.ID)
+	if err := run.writeChangesetFile(path.Join(runner.config.WorkDirectory, DefaultChangesetsDirectory)); err != nil {
+		runner.logger.Warnf("unable to write changeset file: %s", err)
+	}
+	runner.changesetRuns = append(runner.changesetRuns, run)
+	runner.removeChangesetFiles(run)
+	if err := runner.config.Logs.Prune(run.ID, runner.getRunningRunIDs()...); err != nil {
+		runner.logger.Warnf("unable to remove the logs of earlier runs: %s", err)
+	}
+	runner.restarts.Reset()
+	runner.logger.Debugf("pipeline %v changed files: %v", run.ID, run.GetChangedFiles())
 	runner.started = true
+	runner.stopped = false
+	run.Status = PipelineRunStatusSucceeded
+	summary := runner.runPipeline(run)
+	runner.logSummary(run, summary)
+	runner.stopped = true
+}
+
+// removeChangesetFiles removes the changeset files of the runs other
+// than :current which have no execution groups that are still running
+func (runner *Runner) removeChangesetFiles(current *PipelineRun) {
+	var changesetRuns []*PipelineRun
+	for _, run := range runner.changesetRuns {
+		if run == current || runner.isRunRunning(run) {
+			changesetRuns = append(changesetRuns, run)
+			continue
+		}
+		run.removeChangesetFile()
+	}
+	runner.changesetRuns = changesetRuns
+}
+
+// isRunRunning checks whether any execution group started by :run is
+// still running, eg. the final execution group left running when swapping
+func (runner *Runner) isRunRunning(run *PipelineRun) bool {
+	for _, executionGroup := range runner.config.Pipeline {
+		if executionGroup.pipelineRun == run && executionGroup.IsRunning() {
+			return true
+		}
+	}
+	return false
+}
+
+// getRunningRunIDs returns the ids of the runs which started the
+// execution groups that are still running, eg. the final execution
+// group left running when swapping
+func (runner *Runner) getRunningRunIDs() []int {
+	runIDs := []int{}
+	for _, executionGroup := range runner.config.Pipeline {
+		if executionGroup.pipelineRun != nil && executionGroup.IsRunning() {
+			runIDs = append(runIDs, executionGroup.pipelineRun.ID)
+		}
+	}
+	return runIDs
+}
+
+// runPipeline runs the execution groups of the pipeline as soon as
+// the groups they depend on have succeeded, groups downstream of a
+// failed group are skipped and nothing new is started after the run
+// is cancelled. A cancelled run returns once its groups have stopped,
+// except for a final group which is left running while swapping. Groups
+// whose change filter matched none of the changesets since they last
+// succeeded or whose cache
+// is fresh are skipped but count as succeeded for the groups that
+// depend on them, as are the groups before the final one for runs which
+// restart it. The final group is restarted when it exits on its own if
+// the restart policy says so. It returns the lines for the run summary
+func (runner *Runner) runPipeline(run *PipelineRun) []string {
+	const (
+		pending = iota
+		running
+		succeeded
+		unchanged
+		failed
+		skipped
+	)
+	pipeline := runner.config.Pipeline
+	states := make([]int, len(pipeline))
+	completed := make(chan int, len(pipeline))
+	cancelled := run.Cancelled()
+	errs := make([]error, len(pipeline))
+	cacheHashes := make([]string, len(pipeline))
+	var summary []string
+	for index := range pipeline {
+		if run.FinalOnly && !runner.isFinal(index) {
+			states[index] = unchanged
+		} else if pipeline[index].changeFilter.Matches(run.Changeset) {
+			pipeline[index].hasChanges = true
+		}
+	}
+	if run.FinalOnly {
+		summary = append(summary, "restarting the final execution group only")
+	}
+	runningCount := 0
+	for {
+		if run.IsCancelled() {
+			run.Status = PipelineRunStatusTerminated
+		}
+		for hasChanged := true; hasChanged && run.Status != PipelineRunStatusTerminated; {
+			hasChanged = false
+			for index := range pipeline {
+				if states[index] != pending {
+					continue
+				}
+				isReady := true
+				for _, dependency := range runner.getDependencies(index) {
+					switch states[dependency] {
+					case failed, skipped:
+						states[index] = skipped
+					case pending, running:
+						isReady = false
+					}
+				}
+				if states[index] == skipped && runner.isSwapping(index) && pipeline[index].IsRunning() {
+					hasChanged = true
+					summary = append(summary, fmt.Sprintf("[%s] not restarted, the build did not succeed so the previous one keeps running", runner.getGroupName(index)))
+				} else if states[index] == skipped && runner.keepsRunning(index) && pipeline[index].IsRunning() {
+					hasChanged = true
+					pipeline[index].Stop()
+					summary = append(summary, fmt.Sprintf("[%s] stopped, the build did not succeed", runner.getGroupName(index)))
+				} else if states[index] == skipped {
+					hasChanged = true
+					summary = append(summary, fmt.Sprintf("[%s] skipped, an execution group it depends on did not succeed", runner.getGroupName(index)))
+				} else if isReady && !pipeline[index].hasChanges {
+					hasChanged = true
+					states[index] = unchanged
+					summary = append(summary, fmt.Sprintf("[%s] skipped, no changed files match %s", runner.getGroupName(index), pipeline[index].changeFilter))
+				} else if isReady && runner.isCached(index, cacheHashes) {
+					hasChanged = true
+					states[index] = unchanged
+					pipeline[index].hasChanges = false
+					summary = append(summary, fmt.Sprintf("[%s] cached, its inputs haven't changed since it last succeeded", runner.getGroupName(index)))
+				} else if isReady && runner.keepsRunning(index) {
+					hasChanged = true
+					restarted, err := runner.restart(index)
+					if err != nil {
+						states[index] = failed
+						run.Status = PipelineRunStatusFailed
+						summary = append(summary, fmt.Sprintf("[%s] not restarted, the previous one keeps running: %s", runner.getGroupName(index), err))
+						continue
+					} else if !restarted {
+						states[index] = unchanged
+						summary = append(summary, fmt.Sprintf("[%s] not restarted, the build output is identical to the one running", runner.getGroupName(index)))
+						continue
+					}
+					states[index] = running
+					runningCount++
+					runner.startExecutionGroup(index, run, completed, errs)
+				} else if isReady {
+					hasChanged = true
+					states[index] = running
+					runningCount++
+					runner.startExecutionGroup(index, run, completed, errs)
+				}
+			}
+		}
+		if runningCount == 0 {
+			break
+		}
+		if final := len(pipeline) - 1; run.IsCancelled() && runningCount == 1 && states[final] == running && runner.keepsRunning(final) {
+			summary = append(summary, fmt.Sprintf("[%s] left running for the next run", runner.getGroupName(final)))
+			break
+		}
+		var index int
+		select {
+		case index = <-completed:
+		case <-cancelled:
+			// stops receiving from the closed channel
+			cancelled = nil
+			continue
+		}
+		runningCount--
+		results := pipeline[index].GetResults()
+		run.Results = append(run.Results, results...)
+		for _, result := range results {
+			summary = append(summary, fmt.Sprintf("[%s] %s", runner.getGroupName(index), result))
+		}
+		if pipeline[index].background && errs[index] == nil {
+			summary = append(summary, fmt.Sprintf("[%s] ready, running in the background", runner.getGroupName(index)))
+		}
+		if runner.isFinal(index) && !pipeline[index].background && errs[index] != ErrExecutionGroupTerminated {
+			restarted, line := runner.restartAfterExit(index, run, errs[index])
+			if len(line) > 0 {
+				summary = append(summary, line)
+			}
+			if restarted {
+				runningCount++
+				runner.startExecutionGroup(index, run, completed, errs)
+				continue
+			}
+		}
+		switch errs[index] {
+		case nil:
+			states[index] = succeeded
+			pipeline[index].hasChanges = false
+			if len(cacheHashes[index]) > 0 {
+				if err := pipeline[index].cache.Save(cacheHashes[index]); err != nil {
+					runner.logger.Warnf("unable to cache execution group '%s': %s", runner.getGroupName(index), err)
+				}
+			}
+		case ErrExecutionGroupTerminated:
+			states[index] = failed
+			run.Status = PipelineRunStatusTerminated
+		default:
+			states[index] = failed
+			if run.Status != PipelineRunStatusTerminated {
+				run.Status = PipelineRunStatusFailed
+			}
+			summary = append(summary, fmt.Sprintf("[%s] failed, skipping the execution groups which depend on it", runner.getGroupName(index)))
+		}
+	}
+	if run.Status == PipelineRunStatusTerminated {
+		var notStarted []string
+		for index, state := range states {
+			if state == pending {
+				notStarted = append(notStarted, runner.getGroupName(index))
+			}
+		}
+		summary = append(summary, fmt.Sprintf("terminated, not starting: [%s]", strings.Join(notStarted, ", ")))
+	}
+	return summary
+}
+
+// isFinal checks whether the execution group at :index is the final one
+func (runner *Runner) isFinal(index int) bool {
+	return index == len(runner.config.Pipeline)-1
+}
+
+// isSwapping checks whether the execution group at :index is the final
+// one which keeps running until the execution groups before it succeed
+func (runner *Runner) isSwapping(index int) bool {
+	return runner.config.Swap && runner.isFinal(index)
+}
+
+// keepsRunning checks whether the execution group at :index is the final
+// one which keeps running while the execution groups before it run, which
+// it does when swapping or when it runs the build output and is only
+// restarted when that changes
+func (runner *Runner) keepsRunning(index int) bool {
+	return runner.isSwapping(index) ||
+		(runner.isFinal(index) && !runner.config.RestartAlways && runner.runsBuildOutput(index))
+}
+
+// runsBuildOutput checks whether the execution group at :index has a
+// command which runs the build output, relative paths of applications
+// are resolved against the directory their command runs in
+func (runner *Runner) runsBuildOutput(index int) bool {
+	buildOutput := runner.getBuildOutput()
+	for _, command := range runner.config.Pipeline[index].commands {
+		if command.config == nil {
+			continue
+		}
+		directory := command.config.Directory
+		if len(directory) == 0 {
+			directory = runner.config.WorkDirectory
+		}
+		if path.Clean(resolvePath(directory, command.config.Application)) == buildOutput {
+			return true
+		}
+	}
+	return false
+}
+
+// restart stops the previous instance of the final execution group at
+// :index which was left running during the build so that it can be
+// started with the new build, the staged build output is moved to the
+// build output first when swapping. It returns false without stopping anything when the new
+// build is identical to the one which is running
+func (runner *Runner) restart(index int) (bool, error) {
+	executionGroup := runner.config.Pipeline[index]
+	buildOutput := runner.getBuildOutput()
+	newBuildOutput := buildOutput
+	stagedBuildOutput := resolvePath(runner.config.WorkDirectory, runner.config.StagedBuildOutput)
+	if _, err := os.Stat(stagedBuildOutput); len(runner.config.StagedBuildOutput) > 0 && err == nil {
+		newBuildOutput = stagedBuildOutput
+	}
+	hash, err := hashFile(newBuildOutput)
+	if err != nil {
+		runner.logger.Debugf("unable to hash the build output: %s", err)
+	}
+	if runner.isBuildRunning(index, hash) {
+		runner.logger.Infof("build output is identical to the one running, not restarting execution group '%s'", runner.getGroupName(index))
+		if newBuildOutput != buildOutput {
+			os.Remove(newBuildOutput)
+		}
+		return false, nil
+	}
+	if newBuildOutput != buildOutput {
+		if err := os.Rename(newBuildOutput, buildOutput); err != nil {
+			return false, fmt.Errorf("unable to move '%s' to '%s': %s", newBuildOutput, buildOutput, err)
+		}
+		runner.logger.Debugf("moved '%s' to '%s'", newBuildOutput, buildOutput)
+	}
+	if executionGroup.IsRunning() {
+		runner.logger.Infof("build succeeded, stopping the previous instance of execution group '%s'", runner.getGroupName(index))
+		executionGroup.Stop()
+	}
+	runner.runningBuildHash = hash
+	return true, nil
+}
+
+// getBuildOutput returns the path of the build output resolved against
+// the work directory
+func (runner *Runner) getBuildOutput() string {
+	return path.Clean(resolvePath(runner.config.WorkDirectory, runner.config.BuildOutput))
+}
+
+// isBuildRunning checks whether the final execution group at :index is
+// running the build output whose hash is :hash
+func (runner *Runner) isBuildRunning(index int, hash string) bool {
+	return !runner.config.RestartAlways &&
+		len(hash) > 0 &&
+		hash == runner.runningBuildHash &&
+		runner.runsBuildOutput(index) &&
+		runner.config.Pipeline[index].IsRunning()
+}
+
+// restartAfterExit waits for the backoff before the final execution
+// group at :index is restarted after it exited on its own with :err,
+// it returns false if the group shouldn't be restarted because of the
+// restart policy, a crash loop or the run being cancelled along with
+// the line for the run summary
+func (runner *Runner) restartAfterExit(index int, run *PipelineRun, err error) (bool, string) {
+	if !runner.restarts.ShouldRestart(err) || run.IsCancelled() {
+		return false, ""
+	}
+	name := runner.getGroupName(index)
+	backoff, isCrashLooping := runner.restarts.RecordExit(time.Now())
+	if isCrashLooping {
+		banner := strings.Repeat("!", 72)
+		runner.logger.Errorf(
+			"\n%s\n  execution group '%s' exited %v times within %s and looks like it's crash looping\n  it won't be restarted until the next file change\n%s",
+			banner, name, runner.config.CrashLoopExits, runner.config.CrashLoopWindow, banner,
+		)
+		return false, fmt.Sprintf("[%s] crash looping, not restarting it until the next file change", name)
+	}
+	runner.logger.Warnf("execution group '%s' exited, restarting it in %s", name, backoff)
+	select {
+	case <-time.After(backoff):
+	case <-run.Cancelled():
+		run.Status = PipelineRunStatusTerminated
+		return false, fmt.Sprintf("[%s] not restarted, the run was terminated", name)
+	}
+	return true, fmt.Sprintf("[%s] restarted after %s", name, backoff)
+}
+
+// isCached checks whether the execution group at :index can be skipped
+// because its inputs haven't changed, storing the hash of its inputs
+// in :cacheHashes so that it can be saved when the group succeeds
+func (runner *Runner) isCached(index int, cacheHashes []string) bool {
+	hash, err := runner.config.Pipeline[index].getCacheHash()
+	if err != nil {
+		runner.logger.Warnf("unable to hash the inputs of execution group '%s': %s", runner.getGroupName(index), err)
+		return false
+	}
+	cacheHashes[index] = hash
+	return len(hash) > 0 && runner.config.Pipeline[index].cache.IsFresh(hash)
+}
+
+// startExecutionGroup runs the execution group at :index in the
+// background and sends :index to :completed when it's done, which is
+// when it's ready for execution groups which run in the background
+func (runner *Runner) startExecutionGroup(index int, run *PipelineRun, completed chan int, errs []error) {
+	executionGroup := runner.config.Pipeline[index]
+	executionGroup.pipelineRun = run
+	executionGroup.logger = InitLogger(&LoggerConfig{
+		Name:   "run",
+		Format: "production",
+		Level:  runner.config.LogLevel,
+		AdditionalFields: &map[string]interface{}{
+			"submodule": fmt.Sprintf("%v/%s", run.ID, runner.getGroupName(index)),
+		},
+	})
+	for _, command := range executionGroup.commands {
+		command.logs = runner.config.Logs
+		command.logPath = runner.config.Logs.GetPath(run.ID, runner.getGroupName(index), command.GetName())
+	}
+	go func() {
+		if executionGroup.background {
+			errs[index] = executionGroup.RunUntilReady()
+		} else {
+			errs[index] = executionGroup.Run()
+		}
+		completed <- index
+	}()
+}
+
+// getDependencies returns the indices of the execution groups which the
+// one at :index depends on, which is the previous one if unspecified and
+// every other one for the final execution group when swapping
+func (runner *Runner) getDependencies(index int) []int {
+	if runner.isSwapping(index) {
+		dependencies := []int{}
+		for dependency := 0; dependency < index; dependency++ {
+			dependencies = append(dependencies, dependency)
+		}
+		return dependencies
+	}
+	dependencies := runner.config.Pipeline[index].dependsOn
+	if dependencies == nil && index > 0 {
+		return []int{index - 1}
+	}
+	return dependencies
+}
+
+// getGroupName returns the name of the execution group at :index
+func (runner *Runner) getGroupName(index int) string {
+	if name := runner.config.Pipeline[index].GetName(); len(name) > 0 {
+		return name
+	}
+	return fmt.Sprintf("%v", index+1)
+}
+
+// logSummary logs the outcome of :run with a line per command
+func (runner *Runner) logSummary(run *PipelineRun, summary []string) {
+	message := fmt.Sprintf("pipeline %v %s:\n  %s", run.ID, run.Status, strings.Join(summary, "\n  "))
+	switch run.Status {
+	case PipelineRunStatusFailed:
+		runner.logger.Error(message)
+	case PipelineRunStatusTerminated:
+		runner.logger.Debug(message)
+	default:
+		runner.logger.Info(message)
+	}
+}
+
+// SetPipeline terminates the current pipeline if it's running and
+// replaces it with :pipeline for subsequent triggers once its run is done
+func (runner *Runner) SetPipeline(pipeline []*ExecutionGroup) {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	runner.run.Cancel()
+	runner.terminateIfRunning()
+	if runner.done != nil {
+		<-runner.done
+	}
+	runner.config.Pipeline = pipeline
+}
+
+// GetRun returns the latest run of the pipeline, or nil if the
+// pipeline hasn't been triggered
+func (runner *Runner) GetRun() *PipelineRun {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	return runner.run
+}
+
+// Trigger triggers the pipeline for the files in :changeset, the final
+// execution group is left running until the build succeeds if swapping
+// or if it runs the build output
+func (runner *Runner) Trigger(changeset *Changeset) {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	if runner.isShutdown {
+		return
+	}
+	runner.run.Cancel()
+	if final := len(runner.config.Pipeline) - 1; final >= 0 && runner.keepsRunning(final) {
+		runner.terminate(runner.config.Pipeline[:final])
+	} else {
+		runner.terminateIfRunning()
+	}
+	runner.startInBackground(runner.nextRun(changeset, false))
+}
+
+// Restart terminates the pipeline and starts only its final execution
+// group again, without running the execution groups before it. Groups
+// running in the background are left running for it
+func (runner *Runner) Restart() {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	if runner.isShutdown || len(runner.config.Pipeline) == 0 {
+		return
+	}
+	runner.run.Cancel()
+	var executionGroups []*ExecutionGroup
 	for index, executionGroup := range runner.config.Pipeline {
-		executionGroup.logger = InitLogger(&LoggerConfig{
-			Name:   "run",
-			Format: "production",
-			Level:  runner.config.LogLevel,
-			AdditionalFields: &map[string]interface{}{
-				"submodule": fmt.Sprintf("%v/%v/%v]", RunnerTriggerCount, index+1, executionGroupCount),
-			},
-		})
-		executionGroup.Run()
+		if !executionGroup.background || runner.isFinal(index) {
+			executionGroups = append(executionGroups, executionGroup)
+		}
 	}
-	runner.stopped = true
+	runner.terminate(executionGroups)
+	runner.startInBackground(runner.nextRun(nil, true))
 }
 
-// Trigger triggers the pipeline
-func (runner *Runner) Trigger() {
-	runner.started = false
-	runner.stopped = false
+// Shutdown terminates the pipeline and waits for its runs to end before
+// removing their changeset files, nothing is started afterwards
+func (runner *Runner) Shutdown() {
+	runner.mutex.Lock()
+	runner.isShutdown = true
+	runner.run.Cancel()
+	runner.mutex.Unlock()
 	runner.terminateIfRunning()
-	go runner.startPipeline()
+	done := make(chan bool)
+	go func() {
+		runner.waitGroup.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			runner.removeChangesetFiles(nil)
+			return
+		case <-time.After(CommandStopPollInterval):
+			// catches commands which were starting when shutdown began
+			runner.terminateIfRunning()
+		}
+	}
 }
 
 func (runner *Runner) terminateIfRunning() {
+	runner.terminate(runner.config.Pipeline)
+}
+
+// terminate terminates the running execution groups in :executionGroups
+// and waits for them to stop so that the next run starts afresh, groups
+// whose commands were still starting are terminated again until they stop
+func (runner *Runner) terminate(executionGroups []*ExecutionGroup) {
 	defer func() {
 		if r := recover(); r != nil {
 			runner.logger.Warn(r)
 		}
 	}()
-	for index, executionGroup := range runner.config.Pipeline {
+	var terminated []*ExecutionGroup
+	for index, executionGroup := range executionGroups {
 		if executionGroup.IsRunning() {
 			runner.logger.Infof("terminating pipeline %v...", RunnerTriggerCount)
 			executionGroup.Terminate()
-			runner.logger.Infof("terminated pipeline %v", RunnerTriggerCount)
+			terminated = append(terminated, executionGroup)
 		} else {
 			runner.logger.Tracef("execution group %v/%v is not running", index, len(runner.config.Pipeline))
 		}
 	}
+	for _, executionGroup := range terminated {
+		executionGroup.Stop()
+	}
+	if len(terminated) > 0 {
+		runner.logger.Infof("terminated pipeline %v", RunnerTriggerCount)
+	}
 }
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLogsDirectory is where the output of commands is stored
+const DefaultLogsDirectory = ".godev/logs"
+
+// RunLogsExtension is the extension of the log file of a command
+const RunLogsExtension = ".log"
+
+// RunLogsSessionFormat is the layout of the time a session of godev
+// started at which prefixes the run ids of its logs
+const RunLogsSessionFormat = "20060102T150405"
+
+// RunLogs stores the output of the commands in each pipeline run under
+// <Directory>/<session>-<run id>/<execution group>/<command>.log which
+// mirrors the run/<run id>/<execution group> tags in godev's logs. Run
+// ids start from 1 in every session so they're prefixed by the Session
+// which keeps the runs of earlier sessions apart and ordered. When a run
+// starts the logs of the oldest runs are removed so that at most MaxRuns
+// runs are kept and they take up at most MaxSize bytes, 0 means no limit.
+// Output which would take the logs over MaxSize in the meantime (eg. of
+// an application which runs for long) isn't written to the log files
+type RunLogs struct {
+	Directory string
+	Session   string
+	MaxRuns   int
+	MaxSize   int64
+	size      int64
+}
+
+// GetRunName returns the name the logs of the run :runID in the current
+// session are stored under (eg. 20261018T110300-2)
+func (logs *RunLogs) GetRunName(runID int) string {
+	return fmt.Sprintf("%s-%v", logs.Session, runID)
+}
+
+// GetPath returns the path of the log file of the command named
+// :command in the execution group named :group of the run :runID, or
+// an empty string if there are no run logs
+func (logs *RunLogs) GetPath(runID int, group string, command string) string {
+	if logs == nil {
+		return ""
+	}
+	return path.Join(logs.Directory, logs.GetRunName(runID), sanitizeLogName(group), sanitizeLogName(command)+RunLogsExtension)
+}
+
+// GetRuns returns the names of the runs which have logs in the order
+// they started, which is by session and then by run id
+func (logs *RunLogs) GetRuns() ([]string, error) {
+	if logs == nil {
+		return nil, nil
+	}
+	entries, err := ioutil.ReadDir(logs.Directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	type run struct {
+		name    string
+		session string
+		id      int
+	}
+	var runs []run
+	for _, entry := range entries {
+		if session, runID, ok := parseRunName(entry.Name()); ok && entry.IsDir() {
+			runs = append(runs, run{name: entry.Name(), session: session, id: runID})
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].session == runs[j].session {
+			return runs[i].id < runs[j].id
+		}
+		return runs[i].session < runs[j].session
+	})
+	names := []string{}
+	for _, run := range runs {
+		names = append(names, run.name)
+	}
+	return names, nil
+}
+
+// GetSteps returns the steps of the run named :run which have logs, each
+// of them is the name of an execution group and a command in it
+// delimited by a slash (eg. 1/a1b2c3)
+func (logs *RunLogs) GetSteps(run string) ([]string, error) {
+	runDirectory := path.Join(logs.Directory, run)
+	filePaths, err := filepath.Glob(path.Join(runDirectory, "*", "*"+RunLogsExtension))
+	if err != nil {
+		return nil, err
+	}
+	steps := []string{}
+	for _, filePath := range filePaths {
+		step, _ := filepath.Rel(runDirectory, filePath)
+		steps = append(steps, strings.TrimSuffix(filepath.ToSlash(step), RunLogsExtension))
+	}
+	sort.Strings(steps)
+	return steps, nil
+}
+
+// Prune makes room for the logs of the run :currentRunID, the logs of
+// the oldest runs are removed until the limits are kept counting the
+// current run. The logs of the runs :keepRunIDs of the current session
+// are never removed as execution groups which are still running (eg. the
+// application when its restart was skipped) are writing to them
+func (logs *RunLogs) Prune(currentRunID int, keepRunIDs ...int) error {
+	if logs == nil {
+		return nil
+	}
+	runs, err := logs.GetRuns()
+	if err != nil {
+		return err
+	}
+	current := logs.GetRunName(currentRunID)
+	isKept := map[string]bool{current: true}
+	for _, runID := range keepRunIDs {
+		isKept[logs.GetRunName(runID)] = true
+	}
+	count := len(runs) + 1
+	sizes := map[string]int64{}
+	var totalSize int64
+	for _, run := range runs {
+		if run == current {
+			count--
+		}
+		sizes[run] = getDirectorySize(path.Join(logs.Directory, run))
+		totalSize += sizes[run]
+	}
+	for _, run := range runs {
+		isOverCount := logs.MaxRuns > 0 && count > logs.MaxRuns
+		isOverSize := logs.MaxSize > 0 && totalSize > logs.MaxSize
+		if !isOverCount && !isOverSize {
+			break
+		}
+		if isKept[run] {
+			continue
+		}
+		if err := os.RemoveAll(path.Join(logs.Directory, run)); err != nil {
+			return err
+		}
+		count--
+		totalSize -= sizes[run]
+	}
+	atomic.StoreInt64(&logs.size, totalSize)
+	return nil
+}
+
+// OpenFile opens the log file at :filePath for appending, writes to it
+// are dropped once the logs take up MaxSize bytes
+func (logs *RunLogs) OpenFile(filePath string) (io.WriteCloser, error) {
+	file, err := openLogFile(filePath)
+	if err != nil {
+		return nil, err
+	} else if logs == nil || logs.MaxSize <= 0 {
+		return file, nil
+	}
+	return &runLogFile{file: file, logs: logs}, nil
+}
+
+// runLogFile is a log file of RunLogs which keeps count of the size of
+// the logs as it's written to
+type runLogFile struct {
+	file      *os.File
+	logs      *RunLogs
+	truncated int32
+}
+
+// Write appends :data to the log file unless the logs would take up
+// more than their MaxSize, a note is written instead the first time
+func (logFile *runLogFile) Write(data []byte) (int, error) {
+	if atomic.LoadInt32(&logFile.truncated) == 1 {
+		return len(data), nil
+	}
+	if atomic.AddInt64(&logFile.logs.size, int64(len(data))) > logFile.logs.MaxSize {
+		if !atomic.CompareAndSwapInt32(&logFile.truncated, 0, 1) {
+			return len(data), nil
+		}
+		fmt.Fprintf(logFile.file, "\n[godev] the log files take up more than %v bytes, the rest of this output is not logged\n", logFile.logs.MaxSize)
+		return len(data), nil
+	}
+	return logFile.file.Write(data)
+}
+
+// Close closes the log file
+func (logFile *runLogFile) Close() error {
+	return logFile.file.Close()
+}
+
+// parseRunName splits the name of the logs of a run into the session
+// and the run id, ok is false if :name isn't the name of a run's logs
+func parseRunName(name string) (session string, runID int, ok bool) {
+	delimiter := strings.LastIndex(name, "-")
+	if delimiter < 0 {
+		return "", 0, false
+	}
+	session = name[:delimiter]
+	if _, err := time.Parse(RunLogsSessionFormat, session); err != nil {
+		return "", 0, false
+	}
+	runID, err := strconv.Atoi(name[delimiter+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return session, runID, true
+}
+
+// openLogFile opens the log file at :filePath for appending, creating
+// it and its directories if they don't exist
+func openLogFile(filePath string) (*os.File, error) {
+	if err := os.MkdirAll(path.Dir(filePath), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// getDirectorySize returns the total size of the files in :directory
+func getDirectorySize(directory string) int64 {
+	var size int64
+	filepath.Walk(directory, func(filePath string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// sanitizeLogName replaces the characters in :name which can't be in a
+// file name
+func sanitizeLogName(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name)
+}
+
+// parseByteSize parses sizes such as 512, 100KB, 50MB or 1GB into bytes
+// where the units are powers of 1024
+func parseByteSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	bytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || bytes < 0 {
+		return 0, fmt.Errorf("should be a number of bytes optionally followed by one of 'B', 'KB', 'MB' or 'GB'")
+	}
+	return bytes * multiplier, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RunLogsTestSuite struct {
+	suite.Suite
+	logs *RunLogs
+}
+
+func TestRunLogs(t *testing.T) {
+	suite.Run(t, new(RunLogsTestSuite))
+}
+
+func (s *RunLogsTestSuite) SetupTest() {
+	directory, err := ioutil.TempDir("", "godev-logs")
+	if err != nil {
+		panic(err)
+	}
+	s.logs = &RunLogs{Directory: directory, Session: "20261018T100000"}
+}
+
+func (s *RunLogsTestSuite) TearDownTest() {
+	os.RemoveAll(s.logs.Directory)
+}
+
+// writeRun writes :size bytes to the log file of each of the :steps of
+// the run :runID in the session :session
+func (s *RunLogsTestSuite) writeRun(session string, runID int, size int, steps ...string) {
+	logs := &RunLogs{Directory: s.logs.Directory, Session: session}
+	for _, step := range steps {
+		parts := strings.SplitN(step, "/", 2)
+		file, err := openLogFile(logs.GetPath(runID, parts[0], parts[1]))
+		if err != nil {
+			panic(err)
+		}
+		file.Write([]byte(strings.Repeat("x", size)))
+		file.Close()
+	}
+}
+
+func (s *RunLogsTestSuite) TestGetRunName() {
+	assert.Equal(s.T(), "20261018T100000-3", s.logs.GetRunName(3))
+}
+
+func (s *RunLogsTestSuite) TestGetPath() {
+	t := s.T()
+	assert.Equal(t, path.Join(s.logs.Directory, "20261018T100000-3", "build", "a1b2c3.log"), s.logs.GetPath(3, "build", "a1b2c3"))
+	assert.Equal(t, path.Join(s.logs.Directory, "20261018T100000-3", "2", "api_server.log"), s.logs.GetPath(3, "2", "api/server"))
+	var logs *RunLogs
+	assert.Empty(t, logs.GetPath(3, "build", "a1b2c3"))
+}
+
+func (s *RunLogsTestSuite) TestGetRuns() {
+	t := s.T()
+	runs, err := s.logs.GetRuns()
+	assert.Nil(t, err)
+	assert.Empty(t, runs)
+	s.writeRun("20261018T100000", 10, 1, "1/a")
+	s.writeRun("20261018T090000", 2, 1, "1/a")
+	s.writeRun("20261018T100000", 1, 1, "1/a")
+	s.writeRun("20261018T090000", 10, 1, "1/a")
+	for _, name := range []string{"not-a-run", "3", "session-3"} {
+		assert.Nil(t, os.MkdirAll(path.Join(s.logs.Directory, name), os.ModePerm))
+	}
+	runs, err = s.logs.GetRuns()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"20261018T090000-2", "20261018T090000-10", "20261018T100000-1", "20261018T100000-10"}, runs)
+}
+
+func (s *RunLogsTestSuite) TestGetRuns_withoutDirectory() {
+	t := s.T()
+	logs := &RunLogs{Directory: path.Join(s.logs.Directory, "does-not-exist")}
+	runs, err := logs.GetRuns()
+	assert.Nil(t, err)
+	assert.Empty(t, runs)
+}
+
+func (s *RunLogsTestSuite) TestGetSteps() {
+	t := s.T()
+	s.writeRun(s.logs.Session, 1, 1, "test/b", "build/a", "test/a")
+	steps, err := s.logs.GetSteps(s.logs.GetRunName(1))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"build/a", "test/a", "test/b"}, steps)
+	steps, err = s.logs.GetSteps(s.logs.GetRunName(2))
+	assert.Nil(t, err)
+	assert.Empty(t, steps)
+}
+
+func (s *RunLogsTestSuite) TestPrune_byCount() {
+	t := s.T()
+	for runID := 1; runID <= 2; runID++ {
+		s.writeRun("20261018T090000", runID, 1, "1/a")
+		s.writeRun(s.logs.Session, runID, 1, "1/a")
+	}
+	s.logs.MaxRuns = 3
+	assert.Nil(t, s.logs.Prune(3))
+	runs, _ := s.logs.GetRuns()
+	assert.Equal(t, []string{"20261018T100000-1", "20261018T100000-2"}, runs)
+}
+
+func (s *RunLogsTestSuite) TestPrune_bySize() {
+	t := s.T()
+	for runID := 1; runID <= 2; runID++ {
+		s.writeRun("20261018T090000", runID, 100, "1/a", "2/a")
+		s.writeRun(s.logs.Session, runID, 100, "1/a", "2/a")
+	}
+	s.logs.MaxSize = 450
+	assert.Nil(t, s.logs.Prune(3))
+	runs, _ := s.logs.GetRuns()
+	assert.Equal(t, []string{"20261018T100000-1", "20261018T100000-2"}, runs)
+}
+
+func (s *RunLogsTestSuite) TestPrune_keepsRunsStillBeingWritten() {
+	t := s.T()
+	for runID := 1; runID <= 3; runID++ {
+		s.writeRun(s.logs.Session, runID, 1, "1/a")
+	}
+	s.logs.MaxRuns = 1
+	assert.Nil(t, s.logs.Prune(4, 1))
+	runs, _ := s.logs.GetRuns()
+	assert.Equal(t, []string{"20261018T100000-1"}, runs)
+}
+
+func (s *RunLogsTestSuite) TestPrune_withNilRunLogs() {
+	var logs *RunLogs
+	assert.Nil(s.T(), logs.Prune(1))
+}
+
+func (s *RunLogsTestSuite) TestOpenFile_stopsAtMaxSize() {
+	t := s.T()
+	s.writeRun(s.logs.Session, 1, 100, "1/a")
+	s.logs.MaxSize = 150
+	assert.Nil(t, s.logs.Prune(2))
+	filePath := s.logs.GetPath(2, "1", "a")
+	file, err := s.logs.OpenFile(filePath)
+	assert.Nil(t, err)
+	file.Write([]byte(strings.Repeat("y", 30)))
+	file.Write([]byte(strings.Repeat("z", 30)))
+	file.Write([]byte("dropped"))
+	assert.Nil(t, file.Close())
+	contents, _ := ioutil.ReadFile(filePath)
+	assert.Equal(t, strings.Repeat("y", 30)+"\n[godev] the log files take up more than 150 bytes, the rest of this output is not logged\n", string(contents))
+}
+
+func (s *RunLogsTestSuite) TestOpenFile_withNilRunLogs() {
+	t := s.T()
+	var logs *RunLogs
+	file, err := logs.OpenFile(path.Join(s.logs.Directory, "a.log"))
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+}
+
+func (s *RunLogsTestSuite) Test_parseRunName() {
+	t := s.T()
+	session, runID, ok := parseRunName("20261018T100000-12")
+	assert.True(t, ok)
+	assert.Equal(t, "20261018T100000", session)
+	assert.Equal(t, 12, runID)
+	for _, name := range []string{"12", "not-a-run", "20261018T100000-", "20261018-12"} {
+		_, _, ok = parseRunName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func (s *RunLogsTestSuite) Test_parseByteSize() {
+	t := s.T()
+	for size, expected := range map[string]int64{
+		"0":      0,
+		"512":    512,
+		"512B":   512,
+		"2KB":    2048,
+		"10 mb":  10 << 20,
+		"1GB":    1 << 30,
+		" 3MB  ": 3 << 20,
+	} {
+		bytes, err := parseByteSize(size)
+		assert.Nil(t, err, size)
+		assert.Equal(t, expected, bytes, size)
+	}
+	for _, size := range []string{"", "lots", "-1KB", "1TB", "1.5MB"} {
+		_, err := parseByteSize(size)
+		assert.NotNil(t, err, size)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// RestartPolicyNever leaves the final execution group stopped when
+	// it exits on its own until the next file change
+	RestartPolicyNever = "never"
+	// RestartPolicyOnFailure restarts the final execution group when
+	// it exits with an error
+	RestartPolicyOnFailure = "on-failure"
+	// RestartPolicyAlways restarts the final execution group whenever
+	// it exits on its own
+	RestartPolicyAlways = "always"
+)
+
+// RestartBackoffInitial is the delay before the first restart of the
+// final execution group, it doubles with every exit in the crash loop
+// window up to RestartBackoffMax
+const RestartBackoffInitial = time.Second
+
+// RestartBackoffMax is the longest delay before the final execution
+// group is restarted
+const RestartBackoffMax = 30 * time.Second
+
+// validateRestartPolicy checks that :policy is a known restart policy
+func validateRestartPolicy(policy string) error {
+	switch policy {
+	case RestartPolicyNever, RestartPolicyOnFailure, RestartPolicyAlways:
+		return nil
+	}
+	return fmt.Errorf("should be one of '%s', '%s' or '%s'", RestartPolicyNever, RestartPolicyOnFailure, RestartPolicyAlways)
+}
+
+// RestartTracker decides whether the final execution group is restarted
+// after it exits on its own using the Policy, and stops restarting it
+// once it exits CrashLoopExits times within the CrashLoopWindow
+type RestartTracker struct {
+	Policy          string
+	CrashLoopExits  int
+	CrashLoopWindow time.Duration
+	exits           []time.Time
+	mutex           sync.Mutex
+}
+
+// ShouldRestart checks whether an exit with :err should be followed
+// by a restart according to the policy
+func (tracker *RestartTracker) ShouldRestart(err error) bool {
+	switch tracker.Policy {
+	case RestartPolicyAlways:
+		return true
+	case RestartPolicyOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+// RecordExit records an exit at :exitedAt and returns the delay before
+// the restart, or true if the exits within the crash loop window have
+// reached the limit in which case the recorded exits are cleared
+func (tracker *RestartTracker) RecordExit(exitedAt time.Time) (time.Duration, bool) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	var exits []time.Time
+	for _, exit := range tracker.exits {
+		if exitedAt.Sub(exit) < tracker.CrashLoopWindow {
+			exits = append(exits, exit)
+		}
+	}
+	tracker.exits = append(exits, exitedAt)
+	if tracker.CrashLoopExits > 0 && len(tracker.exits) >= tracker.CrashLoopExits {
+		tracker.exits = nil
+		return 0, true
+	}
+	backoff := RestartBackoffInitial
+	for index := 1; index < len(tracker.exits) && backoff < RestartBackoffMax; index++ {
+		backoff *= 2
+	}
+	if backoff > RestartBackoffMax {
+		backoff = RestartBackoffMax
+	}
+	return backoff, false
+}
+
+// Reset clears the recorded exits so that the backoff starts over
+func (tracker *RestartTracker) Reset() {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.exits = nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RunnerRestartTestSuite struct {
+	suite.Suite
+}
+
+func TestRunnerRestartTestSuite(t *testing.T) {
+	suite.Run(t, new(RunnerRestartTestSuite))
+}
+
+func (s *RunnerRestartTestSuite) Test_validateRestartPolicy() {
+	t := s.T()
+	assert.Nil(t, validateRestartPolicy(RestartPolicyNever))
+	assert.Nil(t, validateRestartPolicy(RestartPolicyOnFailure))
+	assert.Nil(t, validateRestartPolicy(RestartPolicyAlways))
+	assert.NotNil(t, validateRestartPolicy("sometimes"))
+}
+
+func (s *RunnerRestartTestSuite) TestShouldRestart() {
+	t := s.T()
+	failure := errors.New("exit status 1")
+	tracker := &RestartTracker{Policy: RestartPolicyNever}
+	assert.False(t, tracker.ShouldRestart(nil))
+	assert.False(t, tracker.ShouldRestart(failure))
+	tracker.Policy = RestartPolicyOnFailure
+	assert.False(t, tracker.ShouldRestart(nil))
+	assert.True(t, tracker.ShouldRestart(failure))
+	tracker.Policy = RestartPolicyAlways
+	assert.True(t, tracker.ShouldRestart(nil))
+	assert.True(t, tracker.ShouldRestart(failure))
+}
+
+func (s *RunnerRestartTestSuite) TestRecordExit_backsOffExponentially() {
+	t := s.T()
+	tracker := &RestartTracker{Policy: RestartPolicyAlways, CrashLoopExits: 0, CrashLoopWindow: time.Hour}
+	now := time.Now()
+	var backoffs []time.Duration
+	for index := 0; index < 7; index++ {
+		backoff, isCrashLooping := tracker.RecordExit(now.Add(time.Duration(index) * time.Second))
+		assert.False(t, isCrashLooping)
+		backoffs = append(backoffs, backoff)
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+		16 * time.Second, RestartBackoffMax, RestartBackoffMax,
+	}, backoffs)
+	tracker.Reset()
+	backoff, _ := tracker.RecordExit(now)
+	assert.Equal(t, RestartBackoffInitial, backoff)
+}
+
+func (s *RunnerRestartTestSuite) TestRecordExit_detectsCrashLoops() {
+	t := s.T()
+	tracker := &RestartTracker{Policy: RestartPolicyAlways, CrashLoopExits: 3, CrashLoopWindow: 10 * time.Second}
+	now := time.Now()
+	_, isCrashLooping := tracker.RecordExit(now)
+	assert.False(t, isCrashLooping)
+	_, isCrashLooping = tracker.RecordExit(now.Add(20 * time.Second))
+	assert.False(t, isCrashLooping, "exits outside the window should not count")
+	_, isCrashLooping = tracker.RecordExit(now.Add(25 * time.Second))
+	assert.False(t, isCrashLooping)
+	_, isCrashLooping = tracker.RecordExit(now.Add(28 * time.Second))
+	assert.True(t, isCrashLooping)
+	backoff, isCrashLooping := tracker.RecordExit(now.Add(29 * time.Second))
+	assert.False(t, isCrashLooping, "exits should be cleared after a crash loop")
+	assert.Equal(t, RestartBackoffInitial, backoff)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// PipelineRunVariables are the variables which are only known when a run
+// starts, references to them are left in commands by interpolate()
+var PipelineRunVariables = []string{
+	EnvironmentRunID,
+	EnvironmentChangedFiles,
+	EnvironmentChangesetFile,
+}
+
+// DefaultChangesetsDirectory is where the files listing the changed
+// files of each run are stored
+const DefaultChangesetsDirectory = ".godev/changesets"
+
+// PipelineRun holds information about a single run of the pipeline
+// which is made available to the commands in it, only the final
+// execution group runs when FinalOnly is set
+type PipelineRun struct {
+	ID            int
+	Changeset     *Changeset
+	ChangesetFile string
+	FinalOnly     bool
+	Results       []*CommandResult
+	Status        string
+	cancelled     chan bool
+	cancelOnce    sync.Once
+}
+
+const (
+	// PipelineRunStatusRunning denotes a run which hasn't completed
+	PipelineRunStatusRunning = "running"
+	// PipelineRunStatusSucceeded denotes a run whose execution groups all completed
+	PipelineRunStatusSucceeded = "succeeded"
+	// PipelineRunStatusFailed denotes a run which was stopped by a failing command
+	PipelineRunStatusFailed = "failed"
+	// PipelineRunStatusTerminated denotes a run which was terminated by a newer one
+	PipelineRunStatusTerminated = "terminated"
+)
+
+// InitPipelineRun creates the run numbered :id for the files in
+// :changeset which is nil for the run when godev starts
+func InitPipelineRun(id int, changeset *Changeset) *PipelineRun {
+	return &PipelineRun{
+		ID:        id,
+		Changeset: changeset,
+		Status:    PipelineRunStatusRunning,
+		cancelled: make(chan bool),
+	}
+}
+
+// Cancel stops the run from restarting its final execution group, used
+// when the run is replaced by a newer one
+func (run *PipelineRun) Cancel() {
+	if run == nil || run.cancelled == nil {
+		return
+	}
+	run.cancelOnce.Do(func() {
+		close(run.cancelled)
+	})
+}
+
+// Cancelled returns a channel which is closed when the run is cancelled
+func (run *PipelineRun) Cancelled() <-chan bool {
+	if run == nil {
+		return nil
+	}
+	return run.cancelled
+}
+
+// IsCancelled checks whether the run has been cancelled
+func (run *PipelineRun) IsCancelled() bool {
+	select {
+	case <-run.Cancelled():
+		return true
+	default:
+		return false
+	}
+}
+
+// GetID returns the run number, or 0 if there isn't a run
+func (run *PipelineRun) GetID() int {
+	if run == nil {
+		return 0
+	}
+	return run.ID
+}
+
+// GetChangedFiles returns the files which triggered this run
+func (run *PipelineRun) GetChangedFiles() []string {
+	if run == nil {
+		return []string{}
+	}
+	return run.Changeset.Files()
+}
+
+// Environment returns the variables describing this run for commands
+func (run *PipelineRun) Environment() []string {
+	if run == nil {
+		return []string{}
+	}
+	return []string{
+		fmt.Sprintf("%s=%v", EnvironmentRunID, run.ID),
+		fmt.Sprintf("%s=%s", EnvironmentChangedFiles, strings.Join(run.GetChangedFiles(), "\n")),
+		fmt.Sprintf("%s=%s", EnvironmentChangesetFile, run.ChangesetFile),
+	}
+}
+
+// Interpolate fills in references to the run variables left in :value
+func (run *PipelineRun) Interpolate(value string) string {
+	for _, variable := range run.Environment() {
+		key, runValue := splitEnvironmentVariable(variable)
+		value = strings.Replace(value, "${"+key+"}", runValue, -1)
+	}
+	return value
+}
+
+// InterpolateArguments fills in the run variables in :arguments, an
+// argument which is only ${GODEV_CHANGED_FILES} becomes one argument
+// per changed file so that paths with spaces are kept intact
+func (run *PipelineRun) InterpolateArguments(arguments []string) []string {
+	interpolated := []string{}
+	for _, argument := range arguments {
+		if argument == "${"+EnvironmentChangedFiles+"}" {
+			interpolated = append(interpolated, run.GetChangedFiles()...)
+			continue
+		}
+		interpolated = append(interpolated, run.Interpolate(argument))
+	}
+	return interpolated
+}
+
+// writeChangesetFile writes the changed files delimited by newlines
+// into a new file in :directory whose path is stored in ChangesetFile
+func (run *PipelineRun) writeChangesetFile(directory string) error {
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(directory, fmt.Sprintf("%v-", run.ID))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	run.ChangesetFile = file.Name()
+	for _, changedFile := range run.GetChangedFiles() {
+		if _, err := file.WriteString(changedFile + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeChangesetFile removes the file written by writeChangesetFile
+func (run *PipelineRun) removeChangesetFile() {
+	if run != nil && len(run.ChangesetFile) > 0 {
+		os.Remove(run.ChangesetFile)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type PipelineRunTestSuite struct {
+	suite.Suite
+	run *PipelineRun
+}
+
+func TestPipelineRunTestSuite(t *testing.T) {
+	suite.Run(t, new(PipelineRunTestSuite))
+}
+
+func (s *PipelineRunTestSuite) SetupTest() {
+	s.run = InitPipelineRun(2, &Changeset{
+		Created:  []string{"/src/new file.go"},
+		Modified: []string{"/src/main.go"},
+	})
+}
+
+func (s *PipelineRunTestSuite) TestEnvironment() {
+	s.run.ChangesetFile = "/tmp/changeset"
+	assert.Equal(s.T(), []string{
+		"GODEV_RUN_ID=2",
+		"GODEV_CHANGED_FILES=/src/main.go\n/src/new file.go",
+		"GODEV_CHANGESET_FILE=/tmp/changeset",
+	}, s.run.Environment())
+}
+
+func (s *PipelineRunTestSuite) TestInterpolateArguments() {
+	assert.Equal(s.T(), []string{
+		"-l",
+		"/src/main.go",
+		"/src/new file.go",
+		"--run=2",
+	}, s.run.InterpolateArguments([]string{"-l", "${GODEV_CHANGED_FILES}", "--run=${GODEV_RUN_ID}"}))
+}
+
+func (s *PipelineRunTestSuite) Test_nilRun() {
+	t := s.T()
+	var run *PipelineRun
+	assert.Equal(t, 0, run.GetID())
+	assert.Equal(t, []string{}, run.GetChangedFiles())
+	assert.Equal(t, []string{}, run.Environment())
+	assert.Equal(t, "${GODEV_RUN_ID}", run.Interpolate("${GODEV_RUN_ID}"))
+	assert.False(t, run.IsCancelled())
+}
+
+func (s *PipelineRunTestSuite) TestCancel() {
+	t := s.T()
+	assert.False(t, s.run.IsCancelled())
+	s.run.Cancel()
+	s.run.Cancel()
+	assert.True(t, s.run.IsCancelled())
+}
+
+func (s *PipelineRunTestSuite) Test_writeChangesetFile() {
+	t := s.T()
+	directory, _ := ioutil.TempDir("", "godev-changesets-")
+	defer os.RemoveAll(directory)
+	assert.Nil(t, s.run.writeChangesetFile(path.Join(directory, DefaultChangesetsDirectory)))
+	assert.Equal(t, path.Join(directory, DefaultChangesetsDirectory), path.Dir(s.run.ChangesetFile))
+	contents, err := ioutil.ReadFile(s.run.ChangesetFile)
+	assert.Nil(t, err)
+	assert.Equal(t, "/src/main.go\n/src/new file.go\n", string(contents))
+	s.run.removeChangesetFile()
+	_, err = os.Stat(s.run.ChangesetFile)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
-	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
 	"sync"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -13,8 +19,9 @@ import (
 type RunnerTestSuite struct {
 	suite.Suite
 	runner               *Runner
-	logs                 bytes.Buffer
+	logs                 lockedBuffer
 	executionGroupLogger *Logger
+	workDirectory        string
 }
 
 func TestRunnerTestSuite(t *testing.T) {
@@ -41,19 +48,385 @@ func (s *RunnerTestSuite) SetupTest() {
 			logger: logger,
 		},
 	}
+	workDirectory, err := ioutil.TempDir("", "godev-runner-")
+	if err != nil {
+		panic(err)
+	}
 	s.runner = InitRunner(&RunnerConfig{
-		Pipeline: executionGroups,
-		LogLevel: "trace",
+		Pipeline:      executionGroups,
+		LogLevel:      "trace",
+		WorkDirectory: workDirectory,
 	})
 	s.runner.logger.SetOutput(&s.logs)
+	s.workDirectory = workDirectory
+}
+
+func (s *RunnerTestSuite) TearDownTest() {
+	os.RemoveAll(s.workDirectory)
 }
 
-func (s *RunnerTestSuite) Test_startPipeline() {
-	s.runner.startPipeline()
+// trigger triggers the pipeline for :changeset and returns its run once
+// it's done
+func (s *RunnerTestSuite) trigger(changeset *Changeset) *PipelineRun {
+	s.runner.Trigger(changeset)
+	s.runner.waitGroup.Wait()
+	return s.runner.GetRun()
+}
+
+// restart restarts the final execution group and returns its run once
+// it's done
+func (s *RunnerTestSuite) restart() *PipelineRun {
+	s.runner.Restart()
+	s.runner.waitGroup.Wait()
+	return s.runner.GetRun()
+}
+
+func (s *RunnerTestSuite) TestTrigger() {
+	s.trigger(nil)
 	assert.Contains(s.T(), s.logs.String(), "starting pipeline")
 	assert.Contains(s.T(), s.logs.String(), "completed pipeline")
 }
 
+func (s *RunnerTestSuite) TestTrigger_stopsAtFailingGroup() {
+	t := s.T()
+	s.runner.config.Pipeline[0].commands = append(s.runner.config.Pipeline[0].commands, mockCommand("false", []string{}, &s.logs))
+	run := s.trigger(nil)
+	assert.Equal(t, PipelineRunStatusFailed, run.Status)
+	assert.Len(t, run.Results, 3)
+	assert.Contains(t, s.logs.String(), fmt.Sprintf("pipeline %v failed", run.ID))
+	assert.Contains(t, s.logs.String(), "'false' failed with exit code 1")
+	assert.Contains(t, s.logs.String(), "[1] failed, skipping the execution groups which depend on it")
+	assert.Contains(t, s.logs.String(), "[2] skipped")
+}
+
+func (s *RunnerTestSuite) TestTrigger_asDependencyGraph() {
+	t := s.T()
+	var logs lockedBuffer
+	s.runner.config.Pipeline = []*ExecutionGroup{
+		&ExecutionGroup{name: "generate", dependsOn: []int{}, commands: []*Command{mockCommand("sleep", []string{"0.1"}, &logs)}},
+		&ExecutionGroup{name: "vet", dependsOn: []int{0}, commands: []*Command{mockCommand("false", []string{}, &logs)}},
+		&ExecutionGroup{name: "build", dependsOn: []int{0}, commands: []*Command{mockCommand("echo", []string{"build"}, &logs)}},
+		&ExecutionGroup{name: "app", dependsOn: []int{1, 2}, commands: []*Command{mockCommand("echo", []string{"app"}, &logs)}},
+		&ExecutionGroup{name: "docs", dependsOn: []int{2}, commands: []*Command{mockCommand("echo", []string{"docs"}, &logs)}},
+	}
+	run := s.trigger(nil)
+	assert.Equal(t, PipelineRunStatusFailed, run.Status)
+	assert.Len(t, run.Results, 4)
+	logsString := s.logs.String()
+	assert.Contains(t, logsString, "[vet] failed, skipping the execution groups which depend on it")
+	assert.Contains(t, logsString, "[app] skipped")
+	assert.Contains(t, logsString, "[docs] command[echo[docs]] 'echo docs' succeeded")
+}
+
+func (s *RunnerTestSuite) TestTrigger_skipsUnchangedGroups() {
+	t := s.T()
+	var logs lockedBuffer
+	s.runner.config.Pipeline = []*ExecutionGroup{
+		&ExecutionGroup{
+			name:         "vendor",
+			changeFilter: &ChangeFilter{Directory: "/work", Patterns: []*ChangePattern{{Pattern: "go.mod"}}},
+			commands:     []*Command{mockCommand("echo", []string{"vendor"}, &logs)},
+		},
+		&ExecutionGroup{name: "app", commands: []*Command{mockCommand("echo", []string{"app"}, &logs)}},
+	}
+	run := s.trigger(&Changeset{Modified: []string{"/work/main.go"}})
+	assert.Equal(t, PipelineRunStatusSucceeded, run.Status)
+	assert.Len(t, run.Results, 1)
+	assert.Contains(t, s.logs.String(), "[vendor] skipped, no changed files match go.mod")
+	s.trigger(nil)
+	assert.Len(t, s.runner.GetRun().Results, 2)
+}
+
+func (s *RunnerTestSuite) TestTrigger_rerunsChangedGroupsUntilTheySucceed() {
+	t := s.T()
+	var logs lockedBuffer
+	marker := path.Join(s.workDirectory, "vendored")
+	s.runner.config.Pipeline = []*ExecutionGroup{
+		&ExecutionGroup{
+			name:         "vendor",
+			changeFilter: &ChangeFilter{Directory: "/work", Patterns: []*ChangePattern{{Pattern: "go.mod"}}},
+			commands:     []*Command{mockCommand("test", []string{"-f", marker}, &logs)},
+		},
+		&ExecutionGroup{name: "app", commands: []*Command{mockCommand("echo", []string{"app"}, &logs)}},
+	}
+	run := s.trigger(&Changeset{Modified: []string{"/work/go.mod"}})
+	assert.Equal(t, PipelineRunStatusFailed, run.Status)
+	run = s.trigger(&Changeset{Modified: []string{"/work/main.go"}})
+	assert.Equal(t, PipelineRunStatusFailed, run.Status)
+	assert.Len(t, run.Results, 1)
+	assert.NotContains(t, s.logs.String(), "[vendor] skipped")
+	ioutil.WriteFile(marker, []byte{}, 0644)
+	run = s.trigger(&Changeset{Modified: []string{"/work/main.go"}})
+	assert.Equal(t, PipelineRunStatusSucceeded, run.Status)
+	assert.Len(t, run.Results, 2)
+	run = s.trigger(&Changeset{Modified: []string{"/work/main.go"}})
+	assert.Equal(t, PipelineRunStatusSucceeded, run.Status)
+	assert.Len(t, run.Results, 1)
+	assert.Contains(t, s.logs.String(), "[vendor] skipped, no changed files match go.mod")
+}
+
+func (s *RunnerTestSuite) TestTrigger_skipsCachedGroups() {
+	t := s.T()
+	var logs lockedBuffer
+	workDirectory, _ := ioutil.TempDir("", "godev-runner-")
+	defer os.RemoveAll(workDirectory)
+	ioutil.WriteFile(path.Join(workDirectory, "go.mod"), []byte("module app"), 0644)
+	s.runner.config.Pipeline = []*ExecutionGroup{
+		&ExecutionGroup{
+			name: "vendor",
+			cache: &ExecutionGroupCache{
+				Directory:     path.Join(workDirectory, DefaultCacheDirectory),
+				Key:           "vendor",
+				Inputs:        []string{"go.mod"},
+				WorkDirectory: workDirectory,
+			},
+			commands: []*Command{mockCommand("echo", []string{"vendor"}, &logs)},
+		},
+		&ExecutionGroup{name: "app", commands: []*Command{mockCommand("echo", []string{"app"}, &logs)}},
+	}
+	s.trigger(nil)
+	assert.Len(t, s.runner.GetRun().Results, 2)
+	run := s.trigger(nil)
+	assert.Equal(t, PipelineRunStatusSucceeded, run.Status)
+	assert.Len(t, run.Results, 1)
+	assert.Contains(t, s.logs.String(), "[vendor] cached")
+	ioutil.WriteFile(path.Join(workDirectory, "go.mod"), []byte("module changed"), 0644)
+	s.trigger(nil)
+	assert.Len(t, s.runner.GetRun().Results, 2)
+}
+
+func (s *RunnerTestSuite) TestTrigger_swapsAfterBuildSucceeds() {
+	t := s.T()
+	var logs lockedBuffer
+	workDirectory, _ := ioutil.TempDir("", "godev-runner-")
+	defer os.RemoveAll(workDirectory)
+	buildOutput := path.Join(workDirectory, "app")
+	stagedBuildOutput := buildOutput + StagedBuildOutputSuffix
+	app := &ExecutionGroup{name: "app", commands: []*Command{mockCommand("sleep", []string{"10"}, &logs)}}
+	s.runner.config.Swap = true
+	s.runner.config.BuildOutput = buildOutput
+	s.runner.config.StagedBuildOutput = stagedBuildOutput
+	s.runner.config.Pipeline = []*ExecutionGroup{
+		&ExecutionGroup{name: "build", commands: []*Command{mockCommand("touch", []string{stagedBuildOutput}, &logs)}},
+		app,
+	}
+	defer s.runner.Shutdown()
+	s.runner.Trigger(nil)
+	for !app.commands[0].IsRunning() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.FileExists(t, buildOutput)
+	previousApp := app.commands[0].cmd
+
+	s.runner.config.Pipeline[0].commands = []*Command{mockCommand("false", []string{}, &logs)}
+	s.runner.Trigger(nil)
+	<-s.runner.done
+	run := s.runner.GetRun()
+	assert.Equal(t, PipelineRunStatusFailed, run.Status)
+	assert.Contains(t, s.logs.String(), "[app] left running for the next run")
+	assert.Contains(t, s.logs.String(), "[app] not restarted, the build did not succeed so the previous one keeps running")
+	assert.True(t, app.IsRunning())
+	assert.Equal(t, previousApp, app.commands[0].cmd)
+
+	s.runner.config.Pipeline[0].commands = []*Command{mockCommand("touch", []string{stagedBuildOutput}, &logs)}
+	s.runner.Trigger(nil)
+	assert.True(t, waitFor(func() bool { return getStartCount(&logs, app.commands[0]) == 2 }))
+	assert.Contains(t, s.logs.String(), "stopping the previous instance of execution group 'app'")
+	_, err := os.Stat(stagedBuildOutput)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func (s *RunnerTestSuite) TestTrigger_keepsChangesetFilesOfRunningGroups() {
+	t := s.T()
+	stagedBuildOutput := path.Join(s.workDirectory, "app"+StagedBuildOutputSuffix)
+	app := &ExecutionGroup{name: "app", commands: []*Command{mockCommand("sleep", []string{"10"}, &s.logs)}}
+	s.runner.config.Swap = true
+	s.runner.config.BuildOutput = path.Join(s.workDirectory, "app")
+	s.runner.config.StagedBuildOutput = stagedBuildOutput
+	s.runner.config.Pipeline = []*ExecutionGroup{
+		&ExecutionGroup{name: "build", commands: []*Command{mockCommand("touch", []string{stagedBuildOutput}, &s.logs)}},
+		app,
+	}
+	s.runner.Trigger(&Changeset{Modified: []string{"/src/main.go"}})
+	for !app.commands[0].IsRunning() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	first := s.runner.GetRun()
+	assert.Equal(t, path.Join(s.workDirectory, DefaultChangesetsDirectory), path.Dir(first.ChangesetFile))
+
+	s.runner.config.Pipeline[0].commands = []*Command{mockCommand("false", []string{}, &s.logs)}
+	s.runner.Trigger(nil)
+	<-s.runner.done
+	second := s.runner.GetRun()
+	assert.FileExists(t, first.ChangesetFile, "the application started by the first run is still running")
+	assert.FileExists(t, second.ChangesetFile)
+
+	s.runner.Trigger(nil)
+	<-s.runner.done
+	_, err := os.Stat(second.ChangesetFile)
+	assert.True(t, os.IsNotExist(err))
+	assert.FileExists(t, first.ChangesetFile)
+
+	s.runner.Shutdown()
+	_, err = os.Stat(first.ChangesetFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func (s *RunnerTestSuite) TestTrigger_skipsRestartForIdenticalBuild() {
+	t := s.T()
+	var logs lockedBuffer
+	workDirectory, _ := ioutil.TempDir("", "godev-runner-")
+	defer os.RemoveAll(workDirectory)
+	stagedBuildOutput := path.Join(workDirectory, "bin", "app"+StagedBuildOutputSuffix)
+	build := func(script string) []*Command {
+		return []*Command{mockCommand("sh", []string{"-c", fmt.Sprintf("mkdir -p %s && printf '#!/bin/sh\n%s\n' > %s && chmod +x %s", path.Dir(stagedBuildOutput), script, stagedBuildOutput, stagedBuildOutput)}, &logs)}
+	}
+	app := &ExecutionGroup{name: "app", commands: []*Command{mockCommand(path.Join(workDirectory, "bin", "app"), []string{}, &logs)}}
+	s.runner.config.Swap = true
+	s.runner.config.WorkDirectory = workDirectory
+	s.runner.config.BuildOutput = "bin/app"
+	s.runner.config.StagedBuildOutput = stagedBuildOutput
+	s.runner.config.Pipeline = []*ExecutionGroup{&ExecutionGroup{name: "build", commands: build("exec sleep 10")}, app}
+	s.runner.config.Logs = &RunLogs{Directory: path.Join(workDirectory, DefaultLogsDirectory), Session: "20261018T100000", MaxRuns: 1}
+	defer s.runner.Shutdown()
+	s.runner.Trigger(nil)
+	for !app.commands[0].IsRunning() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	previousApp := app.commands[0].cmd
+	appLogPath := app.commands[0].logPath
+
+	s.runner.Trigger(nil)
+	<-s.runner.done
+	run := s.runner.GetRun()
+	assert.Equal(t, PipelineRunStatusSucceeded, run.Status)
+	assert.Contains(t, s.logs.String(), "[app] not restarted, the build output is identical to the one running")
+	assert.Equal(t, previousApp, app.commands[0].cmd)
+	assert.True(t, app.IsRunning())
+	_, err := os.Stat(appLogPath)
+	assert.Nil(t, err, "the logs of the run the application is still writing to should not be pruned")
+
+	s.runner.config.Pipeline[0].commands = build("exec sleep 11")
+	s.runner.Trigger(nil)
+	assert.True(t, waitFor(func() bool { return getStartCount(&logs, app.commands[0]) == 2 }))
+	assert.Contains(t, s.logs.String(), "stopping the previous instance of execution group 'app'")
+}
+
+func (s *RunnerTestSuite) TestTrigger_skipsRestartForIdenticalBuildWithoutSwap() {
+	t := s.T()
+	var logs lockedBuffer
+	buildOutput := path.Join(s.workDirectory, "bin", "app")
+	build := func(script string) []*Command {
+		return []*Command{mockCommand("sh", []string{"-c", fmt.Sprintf("mkdir -p %s && printf '#!/bin/sh\n%s\n' > %s.tmp && chmod +x %s.tmp && mv %s.tmp %s", path.Dir(buildOutput), script, buildOutput, buildOutput, buildOutput, buildOutput)}, &logs)}
+	}
+	app := &ExecutionGroup{name: "app", commands: []*Command{mockCommand(buildOutput, []string{}, &logs)}}
+	s.runner.config.BuildOutput = "bin/app"
+	s.runner.config.Pipeline = []*ExecutionGroup{&ExecutionGroup{name: "build", commands: build("exec sleep 10")}, app}
+	defer s.runner.Shutdown()
+	s.runner.Trigger(nil)
+	assert.True(t, waitFor(app.commands[0].IsRunning))
+	previousApp := app.commands[0].cmd
+
+	s.runner.Trigger(nil)
+	assert.True(t, app.IsRunning(), "the application should keep running while building")
+	<-s.runner.done
+	assert.Equal(t, PipelineRunStatusSucceeded, s.runner.GetRun().Status)
+	assert.Contains(t, s.logs.String(), "[app] not restarted, the build output is identical to the one running")
+	assert.Equal(t, previousApp, app.commands[0].cmd)
+	assert.True(t, app.IsRunning())
+
+	s.runner.config.Pipeline[0].commands = build("exec sleep 11")
+	s.runner.Trigger(nil)
+	assert.True(t, waitFor(func() bool { return getStartCount(&logs, app.commands[0]) == 2 }))
+	assert.Contains(t, s.logs.String(), "stopping the previous instance of execution group 'app'")
+
+	s.runner.config.Pipeline[0].commands = []*Command{mockCommand("false", []string{}, &logs)}
+	s.runner.Trigger(nil)
+	<-s.runner.done
+	assert.Contains(t, s.logs.String(), "[app] stopped, the build did not succeed")
+	assert.False(t, app.IsRunning())
+
+	s.runner.config.RestartAlways = true
+	s.runner.config.Pipeline[0].commands = build("exec sleep 11")
+	s.runner.Trigger(nil)
+	assert.True(t, waitFor(app.commands[0].IsRunning))
+	s.runner.Trigger(nil)
+	assert.False(t, app.IsRunning(), "the application should be stopped before building with --restart-always")
+}
+
+func (s *RunnerTestSuite) TestTrigger_stopsFinalGroupBeforeBuildWithoutSwap() {
+	t := s.T()
+	app := s.runner.config.Pipeline[1]
+	app.commands = []*Command{mockCommand("sleep", []string{"10"}, &s.logs)}
+	s.runner.Trigger(nil)
+	for !app.IsRunning() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.runner.config.Pipeline[0].commands = []*Command{mockCommand("false", []string{}, &s.logs)}
+	s.runner.Trigger(nil)
+	assert.False(t, app.IsRunning())
+	s.runner.waitGroup.Wait()
+	assert.Contains(t, s.logs.String(), "[2] skipped, an execution group it depends on did not succeed")
+}
+
+func (s *RunnerTestSuite) TestTrigger_replacesRunBeforeReturning() {
+	t := s.T()
+	s.runner.Trigger(nil)
+	first := s.runner.GetRun()
+	s.runner.Trigger(nil)
+	second := s.runner.GetRun()
+	assert.NotEqual(t, first, second)
+	assert.True(t, first.IsCancelled())
+	assert.False(t, second.IsCancelled())
+	s.runner.waitGroup.Wait()
+	assert.Equal(t, PipelineRunStatusSucceeded, second.Status)
+	assert.Contains(t, s.logs.String(), fmt.Sprintf("pipeline %v terminated", first.ID))
+}
+
+func (s *RunnerTestSuite) TestTrigger_whileExecutionGroupIsStarting() {
+	t := s.T()
+	app := s.runner.config.Pipeline[1]
+	app.commands = []*Command{mockCommand("sleep", []string{"10"}, &s.logs)}
+	s.runner.Trigger(nil)
+	for !app.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	started := time.Now()
+	s.runner.Trigger(nil)
+	assert.True(t, time.Since(started) < 5*time.Second)
+	s.runner.Shutdown()
+	assert.False(t, app.IsRunning())
+}
+
+func (s *RunnerTestSuite) Test_runsBuildOutput() {
+	t := s.T()
+	s.runner.config.WorkDirectory = "/work"
+	s.runner.config.BuildOutput = "bin/app"
+	assert.False(t, s.runner.runsBuildOutput(1))
+	app := mockCommand("./bin/app", []string{}, &s.logs)
+	app.config.Directory = "/work"
+	s.runner.config.Pipeline[1].commands = []*Command{app}
+	assert.True(t, s.runner.runsBuildOutput(1))
+	app.config.Directory = "/work/cmd"
+	assert.False(t, s.runner.runsBuildOutput(1))
+	app.config.Application = "/work/bin/app"
+	assert.True(t, s.runner.runsBuildOutput(1))
+}
+
+// getStartCount returns how many times :command reported that its
+// process started to :logs
+func getStartCount(logs *lockedBuffer, command *Command) int {
+	return strings.Count(logs.String(), fmt.Sprintf("id:%s %s", command.GetID(), CommandProcessStartSymbol))
+}
+
+func (s *RunnerTestSuite) TestTrigger_withChangeset() {
+	t := s.T()
+	run := s.trigger(&Changeset{Modified: []string{"/src/main.go"}})
+	assert.Equal(t, []string{"/src/main.go"}, run.GetChangedFiles())
+	assert.FileExists(t, run.ChangesetFile)
+	assert.Equal(t, run, s.runner.config.Pipeline[0].commands[0].pipelineRun)
+}
+
 func (s *RunnerTestSuite) Test_terminateIfRunning_withoutRunningCommand() {
 	s.runner.terminateIfRunning()
 	assert.Contains(s.T(), s.logs.String(), "is not running")
@@ -88,3 +461,106 @@ func (s *RunnerTestSuite) Test_terminateIfRunning_withRunningCommand() {
 	assert.Contains(s.T(), s.logs.String(), "SIGINT sent to command")
 	assert.Contains(s.T(), s.logs.String(), "terminated pipeline")
 }
+
+func (s *RunnerTestSuite) TestTrigger_writesRunLogs() {
+	t := s.T()
+	directory, err := ioutil.TempDir("", "godev-runner-logs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	s.runner.config.Logs = &RunLogs{Directory: directory, Session: "20261018T100000", MaxRuns: 1}
+	s.runner.config.Pipeline[1].name = "serve"
+	run := s.trigger(nil)
+	logged, err := ioutil.ReadFile(path.Join(directory, fmt.Sprintf("20261018T100000-%v", run.ID), "1", "echo[runner 1.0].log"))
+	assert.Nil(t, err)
+	assert.Equal(t, "runner 1.0\n", string(logged))
+	logged, err = ioutil.ReadFile(path.Join(directory, fmt.Sprintf("20261018T100000-%v", run.ID), "serve", "echo[runner 2].log"))
+	assert.Nil(t, err)
+	assert.Equal(t, "runner 2\n", string(logged))
+	s.trigger(nil)
+	runs, err := s.runner.config.Logs.GetRuns()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{s.runner.config.Logs.GetRunName(s.runner.GetRun().ID)}, runs)
+}
+
+func (s *RunnerTestSuite) TestSetPipeline() {
+	pipeline := []*ExecutionGroup{
+		&ExecutionGroup{
+			commands: []*Command{
+				mockCommand("echo", []string{"replaced"}, &s.logs),
+			},
+		},
+	}
+	s.runner.SetPipeline(pipeline)
+	assert.Equal(s.T(), pipeline, s.runner.config.Pipeline)
+}
+
+func (s *RunnerTestSuite) TestRestart_runsFinalGroupOnly() {
+	t := s.T()
+	run := s.restart()
+	assert.Equal(t, PipelineRunStatusSucceeded, run.Status)
+	assert.Len(t, run.Results, 1)
+	assert.Contains(t, s.logs.String(), "restarting the final execution group only")
+	assert.Contains(t, s.logs.String(), "runner 2")
+}
+
+func (s *RunnerTestSuite) TestShutdown() {
+	t := s.T()
+	s.runner.config.Pipeline[1].commands = []*Command{mockCommand("sleep", []string{"10"}, &s.logs)}
+	s.runner.Trigger(nil)
+	time.Sleep(500 * time.Millisecond)
+	started := time.Now()
+	s.runner.Shutdown()
+	assert.True(t, time.Since(started) < 5*time.Second)
+	run := s.runner.GetRun()
+	assert.Equal(t, PipelineRunStatusTerminated, run.Status)
+	s.runner.Trigger(nil)
+	assert.Equal(t, run, s.runner.GetRun())
+}
+
+func (s *RunnerTestSuite) TestTrigger_restartsFinalGroupUntilCrashLooping() {
+	t := s.T()
+	s.runner.config.Pipeline[1].commands = []*Command{mockCommand("false", []string{}, &s.logs)}
+	s.runner.restarts = &RestartTracker{Policy: RestartPolicyOnFailure, CrashLoopExits: 2, CrashLoopWindow: time.Minute}
+	s.runner.config.CrashLoopExits = 2
+	s.runner.config.CrashLoopWindow = time.Minute
+	run := s.trigger(nil)
+	assert.Equal(t, PipelineRunStatusFailed, run.Status)
+	assert.Len(t, run.Results, 4)
+	assert.Contains(t, s.logs.String(), "execution group '2' exited, restarting it in 1s")
+	assert.Contains(t, s.logs.String(), "[2] restarted after 1s")
+	assert.Contains(t, s.logs.String(), "execution group '2' exited 2 times within 1m0s and looks like it's crash looping")
+	assert.Contains(t, s.logs.String(), "[2] crash looping, not restarting it until the next file change")
+}
+
+func (s *RunnerTestSuite) TestTrigger_doesNotRestartAfterCancel() {
+	t := s.T()
+	s.runner.config.Pipeline[1].commands = []*Command{mockCommand("true", []string{}, &s.logs)}
+	s.runner.restarts = &RestartTracker{Policy: RestartPolicyAlways, CrashLoopExits: 5, CrashLoopWindow: time.Minute}
+	s.runner.Trigger(nil)
+	for !strings.Contains(s.logs.String(), "restarting it in 1s") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.runner.Shutdown()
+	run := s.runner.GetRun()
+	assert.Equal(t, PipelineRunStatusTerminated, run.Status)
+	assert.Contains(t, s.logs.String(), "[2] not restarted, the run was terminated")
+}
+
+func (s *RunnerTestSuite) TestTrigger_withBackgroundGroup() {
+	t := s.T()
+	s.runner.config.Pipeline[0].background = true
+	s.runner.config.Pipeline[0].readiness = &ReadinessCheck{Log: regexp.MustCompile("^stub ready$"), Timeout: 10 * time.Second}
+	s.runner.config.Pipeline[0].commands = []*Command{mockCommand("sh", []string{"-c", "echo stub ready; sleep 10"}, &s.logs)}
+	started := time.Now()
+	run := s.trigger(nil)
+	assert.True(t, time.Since(started) < 5*time.Second)
+	assert.Equal(t, PipelineRunStatusSucceeded, run.Status)
+	assert.Contains(t, s.logs.String(), "[1] ready, running in the background")
+	assert.Contains(t, s.logs.String(), "'echo 'runner 2'' succeeded")
+	assert.True(t, s.runner.config.Pipeline[0].IsRunning())
+	s.runner.Restart()
+	s.runner.waitGroup.Wait()
+	assert.True(t, s.runner.config.Pipeline[0].IsRunning(), "restarts should leave background groups running")
+	s.runner.Shutdown()
+	assert.False(t, s.runner.config.Pipeline[0].IsRunning())
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -77,7 +78,7 @@ func ensureCLIStartSetsRunFlag(t *testing.T, withArgs []string, runFlagName stri
 	})
 }
 
-func mockCommand(application string, arguments []string, logOutput *bytes.Buffer) *Command {
+func mockCommand(application string, arguments []string, logOutput io.Writer) *Command {
 	command := &Command{
 		id: fmt.Sprintf("%s%v", application, arguments),
 		config: &CommandConfig{
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -112,6 +114,20 @@ func fileExists(pathToFile string) bool {
 	return true
 }
 
+// hashFile returns the sha256 hash of the contents of the file at :pathToFile
+func hashFile(pathToFile string) (string, error) {
+	file, err := os.Open(pathToFile)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
 func sliceContainsString(slice []string, search string) bool {
 	for _, sliceItem := range slice {
 		if search == sliceItem {
@@ -120,3 +136,14 @@ func sliceContainsString(slice []string, search string) bool {
 	}
 	return false
 }
+
+// splitNonEmpty splits :value by :delimiter and discards empty items
+func splitNonEmpty(value string, delimiter string) []string {
+	var items []string
+	for _, item := range strings.Split(value, delimiter) {
+		if trimmedItem := strings.TrimSpace(item); len(trimmedItem) > 0 {
+			items = append(items, trimmedItem)
+		}
+	}
+	return items
+}
@@ -85,6 +85,17 @@ func (s *UtilsTestSuite) Test_fileExists_onDirectory() {
 	assert.False(s.T(), fileExists(getCurrentWorkingDirectory()))
 }
 
+func (s *UtilsTestSuite) Test_hashFile() {
+	t := s.T()
+	hash, err := hashFile(path.Join(getCurrentWorkingDirectory(), "/data/test-changes/api/handler.txt"))
+	assert.Nil(t, err)
+	assert.Len(t, hash, 64)
+	otherHash, _ := hashFile(path.Join(getCurrentWorkingDirectory(), "/data/test-changes/api/kind.txt"))
+	assert.NotEqual(t, hash, otherHash)
+	_, err = hashFile(path.Join(getCurrentWorkingDirectory(), "/does/not/exist"))
+	assert.NotNil(t, err)
+}
+
 func (s *UtilsTestSuite) Test_sliceContainsString() {
 	testSlice := []string{"a", "b", "c"}
 	// test the happy path
@@ -15,6 +15,7 @@ import (
 // WatcherConfig is for configuring Watcher
 type WatcherConfig struct {
 	FileExtensions []string
+	FilePaths      []string
 	IgnoredNames   []string
 	RefreshRate    time.Duration
 	LogLevel       LogLevel
@@ -88,10 +89,10 @@ func (fw *Watcher) watchRoutine(tick <-chan time.Time, stop chan bool, handler W
 			}
 		case event := <-fw.watcher.Events:
 			eventToAdd := WatcherEvent(event)
-			if eventToAdd.IsAnyOf(fw.config.FileExtensions) {
+			if eventToAdd.IsAnyOf(fw.config.FileExtensions) || sliceContainsString(fw.config.FilePaths, eventToAdd.FilePath()) {
 				fw.events = append(fw.events, eventToAdd)
 				tick = time.After(2 * time.Second)
-			} else if eventToAdd.FileType() == WatcherFileTypeDir {
+			} else if eventToAdd.FileType() == WatcherFileTypeDir && !fw.isIgnoredName(path.Base(eventToAdd.FilePath())) {
 				fw.Watch(eventToAdd.FilePath())
 			}
 		case shouldWeStop := <-stop:
@@ -99,7 +100,7 @@ func (fw *Watcher) watchRoutine(tick <-chan time.Time, stop chan bool, handler W
 			fw.watchMutex = make(chan bool)
 			onDone()
 			if shouldWeStop {
-				break
+				return
 			}
 		default:
 		}
@@ -116,6 +117,14 @@ func (fw *Watcher) RecursivelyWatch(directoryPath string) {
 	}
 }
 
+// WatchFiles watches the directories of :filePaths so that changes
+// to them are picked up regardless of their extension
+func (fw *Watcher) WatchFiles(filePaths []string) {
+	for _, filePath := range filePaths {
+		fw.Watch(path.Dir(filePath))
+	}
+}
+
 // Watch is here for watching a single directory
 func (fw *Watcher) Watch(directoryPath string) {
 	fw.assertDirectoryIntegrity(directoryPath)
@@ -151,9 +160,10 @@ func (fw *Watcher) getDedupedEvents() []WatcherEvent {
 	return eventsToProcess
 }
 
-// isIgnoredName checks whether the name was faulty
+// isIgnoredName checks whether the name was faulty, the directory
+// godev keeps its caches and logs in is always ignored
 func (fw *Watcher) isIgnoredName(name string) bool {
-	ignore := false
+	ignore := name == path.Dir(DefaultLogsDirectory)
 	if fw.config == nil {
 		return ignore
 	}
@@ -160,6 +160,7 @@ func (s *WatcherTestSuite) Test_isIgnoredName() {
 		},
 	}
 	assert.Truef(s.T(), w.isIgnoredName(ignoredName), "expected '%s' to be ignored but it wasn't", ignoredName)
+	assert.Truef(s.T(), w.isIgnoredName(".godev"), "expected '.godev' to be ignored but it wasn't")
 	for _, nameToWatch := range watchedNames {
 		assert.Falsef(s.T(), w.isIgnoredName(nameToWatch), "expected '%s' to not be ignored but it was", nameToWatch)
 	}